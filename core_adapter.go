@@ -0,0 +1,172 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/ninenhan/go-workflow/flow"
+	"github.com/ninenhan/go-workflow/fn"
+)
+
+// PhaseUnitAdapter 让 PhaseUnit 作为 Graph 节点执行。
+// 每次执行复制一份原型单元，放入单独的 Pipeline 运行，IfUnit/WhileUnit 的嵌套单元照常展开；
+// Pipeline 的 Env 为各节点的 Data，节点 Input 非空时作为单元输入
+type PhaseUnitAdapter struct {
+	Unit
+	Phase    flow.PhaseUnit
+	registry *Registry
+}
+
+var _ ExecutableUnit = (*PhaseUnitAdapter)(nil)
+
+func AdaptPhaseUnit(name string, phase flow.PhaseUnit) *PhaseUnitAdapter {
+	return &PhaseUnitAdapter{Unit: Unit{ID: name, UnitName: name}, Phase: phase}
+}
+
+func (a *PhaseUnitAdapter) GetUnitMeta() *Unit {
+	return &a.Unit
+}
+
+func (a *PhaseUnitAdapter) Execute(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+	phase := cloneValue(a.Phase)
+	if self.Input != nil {
+		// Input 已由引擎渲染，不再作为模板处理
+		io := flow.IOConfig{}
+		if cfg := phase.GetIOConfig(); cfg != nil {
+			io = *cfg
+		}
+		io.Input = flow.Input{Data: self.Input.Data, DataType: self.Input.DataType}
+		setIOConfig(phase, &io)
+	}
+	env := make(map[string]any, len(state))
+	for name, r := range state {
+		if r != nil {
+			env[name] = r.Data
+		}
+	}
+	p := flow.NewPipeline([]flow.PhaseUnit{phase})
+	p.Context.Env = env
+	if err := p.RunContext(ctx); err != nil {
+		return nil, err
+	}
+	return &ExecutionResult{NodeName: self.Name, Data: p.LastOutput.Data}, nil
+}
+
+// UnmarshalJSON 参数解码到被适配的单元，单元没有的字段视为错误
+func (a *PhaseUnitAdapter) UnmarshalJSON(data []byte) error {
+	return decodeParams(data, a.Phase)
+}
+
+func (a *PhaseUnitAdapter) Describe(d *flow.UnitDescriptor) {
+	if desc, ok := a.registryOrDefault().phases.Describe(a.UnitName); ok {
+		*d = desc
+	}
+}
+
+func (a *PhaseUnitAdapter) registryOrDefault() *Registry {
+	if a.registry != nil {
+		return a.registry
+	}
+	return DefaultRegistry
+}
+
+// setIOConfig PhaseUnit 接口没有设置方法，嵌入 BaseUnit 的单元通过 BaseUnit.SetIOConfig 设置
+func setIOConfig(phase flow.PhaseUnit, io *flow.IOConfig) {
+	if u, ok := phase.(interface{ SetIOConfig(*flow.IOConfig) }); ok {
+		u.SetIOConfig(io)
+	}
+}
+
+// ExecutableUnitAdapter 让 ExecutableUnit 在 Pipeline 中执行：
+// Pipeline 的 Env 作为节点状态，单元输入作为节点 Input，结果的 Data 作为输出
+type ExecutableUnitAdapter struct {
+	flow.BaseUnit
+	Unit     ExecutableUnit `json:"-"`
+	registry *Registry
+}
+
+var _ flow.PhaseUnit = (*ExecutableUnitAdapter)(nil)
+
+func AdaptExecutableUnit(name string, unit ExecutableUnit) *ExecutableUnitAdapter {
+	return &ExecutableUnitAdapter{BaseUnit: flow.BaseUnit{UnitName: name}, Unit: unit}
+}
+
+func (a *ExecutableUnitAdapter) Execute(ctx *flow.PipelineContext, input *flow.Input) (*flow.Output, error) {
+	state := make(ContextMap, len(ctx.Env))
+	for k, v := range ctx.Env {
+		state[k] = SimpleResult(v)
+	}
+	node := &Node{ID: a.GetID(), Name: a.GetID(), UnitID: a.UnitName}
+	if input != nil {
+		node.Input = &Input{Data: input.Data, DataType: input.DataType}
+	}
+	runCtx := ctx.Context
+	if runCtx == nil {
+		runCtx = context.Background()
+	}
+	result, err := a.Unit.Execute(runCtx, state, node)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return &flow.Output{}, nil
+	}
+	return &flow.Output{Data: result.Data}, nil
+}
+
+// UnmarshalJSON 基础属性解码到 BaseUnit，其余作为参数解码到被适配的单元；
+// 既不属于 BaseUnit 也不属于单元的字段视为错误；没有的字段取 default 标签的值，解码后检查单元的必填字段
+func (a *ExecutableUnitAdapter) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.BaseUnit); err != nil {
+		return err
+	}
+	if a.Unit == nil {
+		return nil
+	}
+	if err := fn.ApplyDefaults(a.Unit); err != nil {
+		return fmt.Errorf("unit %s: %w", a.UnitName, err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	own := jsonFields(a.Unit)
+	for name := range jsonFields(&a.BaseUnit) {
+		if !own[name] {
+			delete(fields, name)
+		}
+	}
+	params, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	if err := decodeParams(params, a.Unit); err != nil {
+		return fmt.Errorf("unit %s: invalid params: %w", a.UnitName, err)
+	}
+	if err := checkRequired(a.Unit); err != nil {
+		return fmt.Errorf("unit %s: invalid params: %w", a.UnitName, err)
+	}
+	return nil
+}
+
+// jsonFields v 可按 JSON 解码的字段名
+func jsonFields(v any) map[string]bool {
+	props, _ := fn.JSONSchema(reflect.TypeOf(v))["properties"].(map[string]any)
+	names := make(map[string]bool, len(props))
+	for name := range props {
+		names[name] = true
+	}
+	return names
+}
+
+func (a *ExecutableUnitAdapter) Describe(d *flow.UnitDescriptor) {
+	r := a.registry
+	if r == nil {
+		r = DefaultRegistry
+	}
+	if desc, ok := r.Describe(a.UnitName); ok {
+		*d = desc
+	}
+}
@@ -0,0 +1,68 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ninenhan/go-workflow/flow"
+)
+
+// upperPhase 测试用的 PhaseUnit，将输入转为大写并加上 Prefix
+type upperPhase struct {
+	flow.BaseUnit
+	Prefix string `json:"prefix"`
+}
+
+func (u *upperPhase) GetUnitName() string {
+	return "upperPhase"
+}
+
+func (u *upperPhase) Execute(ctx *flow.PipelineContext, input *flow.Input) (*flow.Output, error) {
+	return &flow.Output{Data: u.Prefix + strings.ToUpper(fmt.Sprint(input.Data))}, nil
+}
+
+func TestPhaseUnitAsGraphNode(t *testing.T) {
+	r := NewRegistry()
+	if err := r.RegisterPhaseUnit("test/upper", &upperPhase{}); err != nil {
+		t.Fatal(err)
+	}
+	g, err := r.BuildGraphFromJSON([]byte(`{"nodes": {"u": {"unit_id": "test/upper", "params": {"prefix": ">"}, "input": {"data": "abc"}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := g.RunWithDSL(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.State["u"].Data != ">ABC" {
+		t.Fatalf("unexpected output %v", res.State["u"].Data)
+	}
+	if _, err := r.NewUnit("test/upper", map[string]any{"prefx": ">"}); err == nil {
+		t.Fatal("unknown param accepted by phase adapter")
+	}
+}
+
+func TestExecutableUnitInPipeline(t *testing.T) {
+	r := newTestRegistry(t)
+	units, err := r.Phases().ParsePhaseUnits([]byte(`[{"unit_name": "test/greet@1.0.0", "id": "g", "greeting": "hi", "suffix": "!"}]`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := flow.NewPipeline(units)
+	if err := p.RunContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if p.LastOutput.Data != "hi!" {
+		t.Fatalf("unexpected output %v", p.LastOutput.Data)
+	}
+	for _, raw := range []string{
+		`[{"unit_name": "test/greet@1.0.0", "greeting": "hi", "sufix": "!"}]`,
+		`[{"unit_name": "test/greet@1.0.0"}]`,
+	} {
+		if _, err := r.Phases().ParsePhaseUnits([]byte(raw), ""); err == nil {
+			t.Errorf("invalid params accepted: %s", raw)
+		}
+	}
+}
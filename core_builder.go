@@ -0,0 +1,208 @@
+package workflow
+
+import (
+	"fmt"
+	"slices"
+)
+
+// DSL 构建器：每一步都接在显式记录的游标节点之后，同样的构建代码总是得到同样的图。
+//
+//	g := NewDSLGraph().
+//		StartWith("classify", classify).
+//		Branch("route", route, pick).
+//		Case("refund", "refund", handleRefund).
+//		Default("other", handleOther).
+//		Then("reply", reply)
+//	c, err := g.Build()
+
+// DiagBuilder DSL 使用错误
+const DiagBuilder = "builder"
+
+func (g *Graph) builderError(node, format string, args ...any) {
+	g.dslErrors = append(g.dslErrors, Diagnostic{Level: DiagError, Code: DiagBuilder, Node: node, Message: fmt.Sprintf(format, args...)})
+}
+
+// define 新增节点，重名时记录错误
+func (g *Graph) define(name string, node *Node) {
+	if _, exists := g.Nodes[name]; exists {
+		g.builderError(name, "node %s is defined more than once", name)
+	}
+	node.Name = name
+	g.Nodes[name] = node
+}
+
+// attach 将节点接在游标之后，并成为新的游标
+func (g *Graph) attach(name string, node *Node) {
+	if len(g.cursor) == 0 {
+		g.builderError(name, "node %s has nothing to attach to: call StartWith or From first", name)
+	}
+	g.define(name, node)
+	for _, from := range g.cursor {
+		g.Edges[from] = append(g.Edges[from], name)
+	}
+	g.cursor = []string{name}
+}
+
+func (g *Graph) StartWith(name string, fn NodeFunc) *Graph {
+	g.start = name
+	g.define(name, &Node{Execute: fn})
+	g.cursor = []string{name}
+	return g
+}
+
+// From 将游标移到已有节点，之后的节点接在它后面
+func (g *Graph) From(names ...string) *Graph {
+	for _, name := range names {
+		if _, ok := g.Nodes[name]; !ok {
+			g.builderError(name, "From refers to unknown node %s", name)
+		}
+	}
+	g.cursor = slices.Clone(names)
+	return g
+}
+
+func (g *Graph) Then(name string, fn NodeFunc) *Graph {
+	g.attach(name, &Node{Execute: fn})
+	return g
+}
+
+func (g *Graph) Loop(name string, fn NodeFunc, cond LoopCondFunc) *Graph {
+	g.attach(name, &Node{Execute: fn, LoopCond: cond})
+	return g
+}
+
+// Build 校验并编译构建好的图
+func (g *Graph) Build() (*CompiledGraph, error) {
+	return g.Compile()
+}
+
+// BranchBuilder 为分支节点声明具名的分支
+type BranchBuilder struct {
+	g        *Graph
+	name     string
+	cases    map[string]string
+	def      string
+	branches []string // 由 Case/Default 新建的节点，End 之后成为游标
+}
+
+// Branch 添加分支节点：执行 fn 后由 selector 返回分支名，再按 Case/Default 找到下游节点。
+// 不声明 Case 时 selector 直接返回下游节点名。
+func (g *Graph) Branch(name string, fn NodeFunc, selector BranchFunc) *BranchBuilder {
+	b := &BranchBuilder{g: g, name: name, cases: map[string]string{}}
+	g.attach(name, &Node{Execute: fn})
+	g.Nodes[name].Branch = func(result *ExecutionResult, state ContextMap) string {
+		key := selector(result, state)
+		if len(b.cases) == 0 && b.def == "" {
+			return key
+		}
+		if target, ok := b.cases[key]; ok {
+			return target
+		}
+		if b.def != "" {
+			return b.def
+		}
+		return "END"
+	}
+	return b
+}
+
+// Case 分支名为 key 时执行节点 target；fn 为空时 target 指向已有节点（如回到上游形成循环）
+func (b *BranchBuilder) Case(key, target string, fn NodeFunc) *BranchBuilder {
+	if _, dup := b.cases[key]; dup {
+		b.g.builderError(b.name, "branch %s declares case %q more than once", b.name, key)
+	}
+	b.cases[key] = target
+	b.link(target, key, fn)
+	return b
+}
+
+// Default 没有匹配的 Case 时执行的节点；未设置时不匹配即结束该路径
+func (b *BranchBuilder) Default(target string, fn NodeFunc) *BranchBuilder {
+	if b.def != "" {
+		b.g.builderError(b.name, "branch %s declares Default more than once", b.name)
+	}
+	b.def = target
+	b.link(target, "default", fn)
+	return b
+}
+
+func (b *BranchBuilder) link(target, label string, fn NodeFunc) {
+	g := b.g
+	if fn != nil {
+		g.define(target, &Node{Execute: fn})
+		b.branches = append(b.branches, target)
+	}
+	node := g.Nodes[b.name]
+	if !slices.Contains(node.BranchTargets, target) {
+		node.BranchTargets = append(node.BranchTargets, target)
+		g.Edges[b.name] = append(g.Edges[b.name], target)
+	}
+	if node.BranchLabels == nil {
+		node.BranchLabels = map[string]string{}
+	}
+	if prev := node.BranchLabels[target]; prev != "" {
+		label = prev + ", " + label
+	}
+	node.BranchLabels[target] = label
+}
+
+// End 结束分支声明，新建的分支节点成为游标（没有 Case 时为分支节点本身）
+func (b *BranchBuilder) End() *Graph {
+	if len(b.cases) == 0 && b.def == "" {
+		b.g.cursor = []string{b.name}
+	} else {
+		b.g.cursor = slices.Clone(b.branches)
+	}
+	return b.g
+}
+
+// Then 等同于 End().Then(...)，在各分支之后汇聚
+func (b *BranchBuilder) Then(name string, fn NodeFunc) *Graph {
+	return b.End().Then(name, fn)
+}
+
+// ParallelBuilder 并行分支，之后通过 Join 汇聚
+type ParallelBuilder struct {
+	g        *Graph
+	branches []string
+}
+
+// Parallel 在游标之后并发执行 fns，分支节点名为 name_p0、name_p1...
+func (g *Graph) Parallel(name string, fns ...NodeFunc) *ParallelBuilder {
+	if len(g.cursor) == 0 {
+		g.builderError(name, "parallel %s has nothing to attach to: call StartWith or From first", name)
+	}
+	// 由上游节点发起并行
+	for _, from := range g.cursor {
+		if parent, ok := g.Nodes[from]; ok {
+			parent.Parallel = true
+		}
+	}
+	p := &ParallelBuilder{g: g}
+	for i, nodeFunc := range fns {
+		child := fmt.Sprintf("%s_p%d", name, i)
+		g.define(child, &Node{Execute: nodeFunc})
+		for _, from := range g.cursor {
+			g.Edges[from] = append(g.Edges[from], child)
+		}
+		p.branches = append(p.branches, child)
+	}
+	g.cursor = slices.Clone(p.branches)
+	return p
+}
+
+// Join 所有分支完成后执行 name
+func (p *ParallelBuilder) Join(name string, fn NodeFunc) *Graph {
+	return p.JoinWith(name, fn, JoinAll, 0)
+}
+
+// JoinWith 按指定的汇聚方式执行 name，n 仅在 JoinNofM 时使用
+func (p *ParallelBuilder) JoinWith(name string, fn NodeFunc, mode JoinMode, n int) *Graph {
+	p.g.attach(name, &Node{Execute: fn, Join: mode, JoinN: n})
+	return p.g
+}
+
+// End 不汇聚，各分支节点成为游标
+func (p *ParallelBuilder) End() *Graph {
+	return p.g
+}
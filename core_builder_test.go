@@ -0,0 +1,50 @@
+package workflow
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestBuilderBranchCasesAndJoin(t *testing.T) {
+	build := func(pick string) *Graph {
+		g := NewDSLGraph()
+		return g.StartWith("classify", value(pick)).
+			Branch("route", value(nil), func(r *ExecutionResult, state ContextMap) string {
+				return state["classify"].Data.(string)
+			}).
+			Case("refund", "refund", value("R")).
+			Default("other", value("O")).
+			Then("reply", value("done"))
+	}
+	for pick, want := range map[string]string{"refund": "refund", "unknown": "other"} {
+		res, err := build(pick).RunWithDSL(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := res.State[want]; !ok {
+			t.Fatalf("%s: branch %s not taken", pick, want)
+		}
+		if _, ok := res.State["reply"]; !ok {
+			t.Fatalf("%s: join after branch did not run", pick)
+		}
+	}
+	g := build("refund")
+	if !slices.Equal(g.Edges["route"], []string{"refund", "other"}) || !slices.Equal(g.Edges["other"], []string{"reply"}) {
+		t.Fatalf("unexpected edges %v", g.Edges)
+	}
+}
+
+func TestBuilderReportsMisuse(t *testing.T) {
+	g := NewDSLGraph()
+	g.Then("orphan", value(1))
+	g.StartWith("a", value(1)).Then("a", value(2))
+	g.From("missing")
+	ds := g.Validate()
+	if n := len(slices.DeleteFunc(slices.Clone(ds), func(d Diagnostic) bool { return d.Code != DiagBuilder })); n != 3 {
+		t.Fatalf("want 3 builder diagnostics, got %v", ds)
+	}
+	if _, err := g.Build(); err == nil {
+		t.Fatal("build succeeded despite builder errors")
+	}
+}
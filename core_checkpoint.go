@@ -0,0 +1,145 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ninenhan/go-workflow/fn"
+	"github.com/ninenhan/go-workflow/store"
+	"log/slog"
+	"time"
+)
+
+// Checkpoint 一次运行的可恢复状态。
+// 恢复时按 Completed 重放已完成节点（不重新执行），再从未完成的节点继续。
+// 状态经过 JSON 序列化，恢复后 Data 中的数字等类型会变为 JSON 的默认类型。
+type Checkpoint struct {
+	RunID     string          `json:"run_id"`
+	Start     string          `json:"start"`
+	State     ContextMap      `json:"state"`
+	Completed []CompletedNode `json:"completed"`         // 按完成顺序
+	Pending   []string        `json:"pending,omitempty"` // 尚未完成的节点：执行中、排队中或失败待重试
+	// Interrupts 等待人工输入的节点
+	Interrupts []PendingInterrupt `json:"interrupts,omitempty"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+}
+
+// CompletedNode 已完成节点及其选择的下游
+type CompletedNode struct {
+	Node     string   `json:"node"`
+	Next     []string `json:"next,omitempty"`
+	Branched bool     `json:"branched,omitempty"`
+}
+
+type runIDKey struct{}
+
+// WithRunID 指定运行 ID，开启检查点时用于之后的 Resume
+func WithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, runID)
+}
+
+// RunIDFromContext 当前运行的 ID
+func RunIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(runIDKey{}).(string)
+	return id
+}
+
+func newRunID(ctx context.Context) string {
+	if id := RunIDFromContext(ctx); id != "" {
+		return id
+	}
+	return fn.Uuid()
+}
+
+// LoadCheckpoint 读取检查点
+func LoadCheckpoint(ctx context.Context, cs store.CheckpointStore, runID string) (*Checkpoint, error) {
+	data, err := cs.Load(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("decode checkpoint %s: %w", runID, err)
+	}
+	return &cp, nil
+}
+
+// Resume 从检查点恢复运行，已完成的节点不会重新执行
+func (g *Graph) Resume(ctx context.Context, runID string) (*RunResult, error) {
+	if g.Checkpoints == nil {
+		return nil, errors.New("graph has no checkpoint store")
+	}
+	cp, err := LoadCheckpoint(ctx, g.Checkpoints, runID)
+	if err != nil {
+		return nil, err
+	}
+	c, err := g.CompileFrom(cp.Start)
+	if err != nil {
+		return nil, err
+	}
+	return c.resume(ctx, cp, nil)
+}
+
+// resume 按检查点重放已完成节点；inputs 为注入给挂起节点的人工输入
+func (c *CompiledGraph) resume(ctx context.Context, cp *Checkpoint, inputs map[string]any) (*RunResult, error) {
+	s := newScheduler(WithRunID(ctx, cp.RunID), c.graph, c.start, NewState(cp.State))
+	s.inputs = inputs
+	s.replay = map[string][]CompletedNode{}
+	for _, done := range cp.Completed {
+		s.replay[done.Node] = append(s.replay[done.Node], done)
+	}
+	return s.run(c.start)
+}
+
+// replayed 取出节点的下一条已完成记录
+func (s *scheduler) replayed(name string) (CompletedNode, bool) {
+	entries := s.replay[name]
+	if len(entries) == 0 {
+		return CompletedNode{}, false
+	}
+	s.replay[name] = entries[1:]
+	return entries[0], true
+}
+
+// checkpoint 保存当前状态与未完成节点；保存失败只记录日志，不中断运行
+func (s *scheduler) checkpoint(pending []string) {
+	cs := s.g.Checkpoints
+	if cs == nil {
+		return
+	}
+	cp := Checkpoint{
+		RunID:      s.runID,
+		Start:      s.start,
+		State:      s.state.Snapshot(),
+		Completed:  s.log,
+		Pending:    pending,
+		Interrupts: s.interrupts,
+		UpdatedAt:  time.Now(),
+	}
+	data, err := json.Marshal(cp)
+	if err == nil {
+		err = cs.Save(context.WithoutCancel(s.parent), s.runID, data)
+	}
+	if err != nil {
+		slog.Error("保存检查点失败", "run_id", s.runID, "err", err)
+	}
+}
+
+// finalize 运行成功后删除检查点，失败、取消或挂起时保留以便恢复
+func (s *scheduler) finalize(err error, pending []string) {
+	cs := s.g.Checkpoints
+	if cs == nil {
+		if len(s.interrupts) > 0 {
+			slog.Warn("未配置检查点存储，挂起的运行无法恢复", "run_id", s.runID)
+		}
+		return
+	}
+	if err != nil {
+		s.checkpoint(pending)
+		return
+	}
+	if e := cs.Delete(context.WithoutCancel(s.parent), s.runID); e != nil {
+		slog.Error("删除检查点失败", "run_id", s.runID, "err", e)
+	}
+}
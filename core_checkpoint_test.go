@@ -0,0 +1,37 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ninenhan/go-workflow/flow"
+	"github.com/ninenhan/go-workflow/store"
+)
+
+func TestResumeSkipsCompletedNodes(t *testing.T) {
+	c := newCounter()
+	g := NewDSLGraph()
+	g.Checkpoints = store.NewInMemoryCheckpointStore()
+	g.StartWith("a", c.wrap("a", value("A"))).
+		Then("b", c.wrap("b", flaky(1, "down"))).
+		Then("c", c.wrap("c", func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+			return SimpleResult(state["a"].Data), nil
+		}))
+	ctx := WithRunID(context.Background(), "run-1")
+	if _, err := g.RunWithDSL(ctx, nil); err == nil {
+		t.Fatal("want first run to fail")
+	}
+	res, err := g.Resume(context.Background(), "run-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RunID != "run-1" || res.Status != flow.TaskCompleted {
+		t.Fatalf("unexpected result %s %s", res.RunID, res.Status)
+	}
+	if c.get("a") != 1 || c.get("b") != 2 || c.get("c") != 1 {
+		t.Fatalf("unexpected calls %v", c.calls)
+	}
+	if res.State["c"].Data != "A" {
+		t.Fatalf("replayed state not visible downstream: %v", res.State["c"].Data)
+	}
+}
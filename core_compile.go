@@ -0,0 +1,332 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"github.com/ninenhan/go-workflow/fn"
+	"maps"
+	"slices"
+	"strings"
+)
+
+type DiagnosticLevel string
+
+const (
+	DiagError   DiagnosticLevel = "error"
+	DiagWarning DiagnosticLevel = "warning"
+)
+
+// 诊断编码
+const (
+	DiagNoStart          = "no_start"
+	DiagMultipleStarts   = "multiple_starts"
+	DiagMissingStart     = "missing_start"
+	DiagMissingExecute   = "missing_execute"
+	DiagUnknownUnit      = "unknown_unit"
+	DiagInvalidParams    = "invalid_params"
+	DiagUnknownGraph     = "unknown_graph"
+	DiagInvalidForEach   = "invalid_for_each"
+	DiagInvalidCondition = "invalid_condition"
+	DiagMissingSource    = "missing_source"
+	DiagEmptyTarget      = "empty_target"
+	DiagMissingTarget    = "missing_target"
+	DiagUnknownBranch    = "unknown_branch_target"
+	DiagUndeclaredBranch = "undeclared_branch"
+	DiagInvalidJoin      = "invalid_join"
+	DiagInvalidRetry     = "invalid_retry"
+	DiagUnboundedCycle   = "unbounded_cycle"
+	DiagUnreachable      = "unreachable"
+)
+
+// Diagnostic 图校验的单条结果
+type Diagnostic struct {
+	Level   DiagnosticLevel `json:"level"`
+	Code    string          `json:"code"`
+	Node    string          `json:"node,omitempty"`
+	Target  string          `json:"target,omitempty"`
+	Message string          `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("[%s] %s: %s", d.Level, d.Code, d.Message)
+}
+
+type Diagnostics []Diagnostic
+
+func (ds Diagnostics) HasErrors() bool {
+	return slices.ContainsFunc(ds, func(d Diagnostic) bool { return d.Level == DiagError })
+}
+
+// Errors 只返回错误级别的诊断
+func (ds Diagnostics) Errors() Diagnostics {
+	var out Diagnostics
+	for _, d := range ds {
+		if d.Level == DiagError {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// Err 存在错误时返回 *CompileError
+func (ds Diagnostics) Err() error {
+	if !ds.HasErrors() {
+		return nil
+	}
+	return &CompileError{Diagnostics: ds}
+}
+
+// CompileError 图校验失败
+type CompileError struct {
+	Diagnostics Diagnostics
+}
+
+func (e *CompileError) Error() string {
+	var msgs []string
+	for _, d := range e.Diagnostics.Errors() {
+		msgs = append(msgs, d.String())
+	}
+	return "graph invalid: " + strings.Join(msgs, "; ")
+}
+
+// CompiledGraph 校验通过的图，节点与边均为拷贝，编译后对原 Graph 的修改不会影响它。
+// 运行过程不会修改 CompiledGraph，同一个实例可以被多个请求并发 Run；每次运行的状态与渲染后的输入只属于该次运行。
+type CompiledGraph struct {
+	graph       *Graph
+	start       string
+	diagnostics Diagnostics
+}
+
+func (c *CompiledGraph) Start() string {
+	return c.start
+}
+
+// Diagnostics 编译时产生的警告
+func (c *CompiledGraph) Diagnostics() Diagnostics {
+	return c.diagnostics
+}
+
+func (c *CompiledGraph) Run(ctx context.Context, initial ContextMap) (*RunResult, error) {
+	return newScheduler(ctx, c.graph, c.start, NewState(initial)).run(c.start)
+}
+
+// Validate 校验以 DSL 起点（或唯一的无入边节点）开始的图
+func (g *Graph) Validate() Diagnostics {
+	_, ds := g.validate(g.start)
+	return ds
+}
+
+// Compile 校验并生成可执行的图
+func (g *Graph) Compile() (*CompiledGraph, error) {
+	return g.CompileFrom(g.start)
+}
+
+// CompileFrom 以指定节点为起点校验并生成可执行的图
+func (g *Graph) CompileFrom(start string) (*CompiledGraph, error) {
+	start, ds := g.validate(start)
+	if err := ds.Err(); err != nil {
+		return nil, err
+	}
+	return &CompiledGraph{graph: g.clone(start), start: start, diagnostics: ds}, nil
+}
+
+// clone 拷贝节点与边，供编译后的图独立使用
+func (g *Graph) clone(start string) *Graph {
+	cp := &Graph{
+		Nodes:          make(map[string]*Node, len(g.Nodes)),
+		Edges:          make(map[string][]string, len(g.Edges)),
+		Hooks:          g.Hooks,
+		FailFast:       g.FailFast,
+		MaxConcurrency: g.MaxConcurrency,
+		Timeout:        g.Timeout,
+		Checkpoints:    g.Checkpoints,
+		Events:         g.Events,
+		EventRetention: g.EventRetention,
+		Listeners:      slices.Clone(g.Listeners),
+		Registry:       g.Registry,
+		start:          start,
+	}
+	for name, node := range g.Nodes {
+		n := *node
+		if node.Input != nil {
+			input := *node.Input
+			n.Input = &input
+		}
+		n.BranchLabels = maps.Clone(node.BranchLabels)
+		switch {
+		case n.Execute != nil:
+		case n.UnitID != "":
+			// 每个节点使用各自的实例，参数已在校验时检查
+			if unit, err := g.registry().NewUnit(n.UnitID, n.Params); err == nil {
+				n.Execute = unit.Execute
+			}
+		case n.Subgraph != nil:
+			n.Execute = n.Subgraph.Execute
+		case n.ForEach != nil:
+			forEach := *n.ForEach
+			forEach.registry = g.registry()
+			n.ForEach = &forEach
+			n.Execute = forEach.Execute
+		}
+		cp.Nodes[name] = &n
+	}
+	for from, tos := range g.Edges {
+		cp.Edges[from] = slices.Clone(tos)
+	}
+	if g.Conditions != nil {
+		cp.Conditions = make(map[string]map[string]EdgeCondition, len(g.Conditions))
+		for from, conds := range g.Conditions {
+			cp.Conditions[from] = maps.Clone(conds)
+		}
+	}
+	return cp
+}
+
+// successors 边与声明的分支目标
+func (g *Graph) successors(name string) []string {
+	out := slices.Clone(g.Edges[name])
+	if node := g.Nodes[name]; node != nil {
+		out = append(out, node.BranchTargets...)
+	}
+	return out
+}
+
+func (g *Graph) validate(start string) (string, Diagnostics) {
+	ds := slices.Clone(g.dslErrors)
+	add := func(level DiagnosticLevel, code, node, target, format string, args ...any) {
+		ds = append(ds, Diagnostic{Level: level, Code: code, Node: node, Target: target, Message: fmt.Sprintf(format, args...)})
+	}
+	names := slices.Sorted(maps.Keys(g.Nodes))
+	isTarget := func(to string) bool {
+		_, ok := g.Nodes[to]
+		return ok || to == "END"
+	}
+
+	// 节点
+	incoming := map[string]int{}
+	for _, name := range names {
+		node := g.Nodes[name]
+		_, unitFound := g.registry().Find(node.UnitID)
+		if node.UnitID != "" && !unitFound {
+			add(DiagError, DiagUnknownUnit, name, "", "node %s uses unknown unit %s", name, node.UnitID)
+		} else if node.Execute == nil && node.UnitID != "" {
+			if _, err := g.registry().NewUnit(node.UnitID, node.Params); err != nil {
+				add(DiagError, DiagInvalidParams, name, "", "node %s: %v", name, err)
+			}
+		} else if node.Execute == nil && node.Subgraph == nil && node.ForEach == nil {
+			add(DiagError, DiagMissingExecute, name, "", "node %s has no Execute", name)
+		} else if node.Execute == nil && node.Subgraph != nil {
+			if _, err := node.Subgraph.resolve(); err != nil {
+				add(DiagError, DiagUnknownGraph, name, "", "node %s: %v", name, err)
+			}
+		} else if node.Execute == nil {
+			if _, err := node.ForEach.body(g.registry()); err != nil {
+				add(DiagError, DiagInvalidForEach, name, "", "node %s: %v", name, err)
+			}
+			if len(fn.ParsePathExpr(node.ForEach.Items)) == 0 {
+				add(DiagError, DiagInvalidForEach, name, "", "node %s has an invalid items path %q", name, node.ForEach.Items)
+			}
+		}
+		if r := node.Retry; r != nil && (r.MaxAttempts < 0 || r.Delay < 0 || r.MaxDelay < 0 || r.Jitter < 0 || r.Jitter > 1 || r.AttemptTimeout < 0) {
+			add(DiagError, DiagInvalidRetry, name, "", "node %s has an invalid retry policy", name)
+		}
+		if node.Branch != nil && len(node.BranchTargets) == 0 {
+			add(DiagWarning, DiagUndeclaredBranch, name, "", "node %s branches without declaring BranchTargets", name)
+		}
+		for _, to := range node.BranchTargets {
+			if !isTarget(to) {
+				add(DiagError, DiagUnknownBranch, name, to, "node %s declares branch target %s which does not exist", name, to)
+			}
+			incoming[to]++
+		}
+	}
+
+	// 边
+	for _, from := range slices.Sorted(maps.Keys(g.Edges)) {
+		if _, ok := g.Nodes[from]; !ok {
+			add(DiagError, DiagMissingSource, from, "", "edge source %s does not exist", from)
+		}
+		for _, to := range g.Edges[from] {
+			switch {
+			case strings.TrimSpace(to) == "":
+				add(DiagError, DiagEmptyTarget, from, "", "node %s has an edge with empty target", from)
+			case !isTarget(to):
+				add(DiagError, DiagMissingTarget, from, to, "edge %s -> %s points to a missing node", from, to)
+			default:
+				incoming[to]++
+			}
+		}
+	}
+
+	g.validateConditions(add)
+
+	// 起点
+	if start == "" {
+		var roots []string
+		for _, name := range names {
+			if incoming[name] == 0 {
+				roots = append(roots, name)
+			}
+		}
+		switch len(roots) {
+		case 0:
+			add(DiagError, DiagNoStart, "", "", "no start node: every node has incoming edges")
+		case 1:
+			start = roots[0]
+		default:
+			add(DiagError, DiagMultipleStarts, "", "", "multiple start candidates: %s", strings.Join(roots, ", "))
+		}
+	} else if _, ok := g.Nodes[start]; !ok {
+		add(DiagError, DiagMissingStart, start, "", "start node %s does not exist", start)
+		start = ""
+	}
+
+	// 汇聚
+	for _, name := range names {
+		node := g.Nodes[name]
+		if node.Join == JoinNofM && (node.JoinN <= 0 || node.JoinN > incoming[name]) {
+			add(DiagError, DiagInvalidJoin, name, "", "node %s waits for %d of %d upstream nodes", name, node.JoinN, incoming[name])
+		}
+	}
+
+	if start == "" {
+		return start, ds
+	}
+
+	// 可达性与环：环上必须有 Branch 节点作为出口
+	reached := map[string]bool{}
+	var stack []string
+	onStack := map[string]bool{}
+	var dfs func(string)
+	dfs = func(u string) {
+		reached[u] = true
+		onStack[u] = true
+		stack = append(stack, u)
+		for _, v := range g.successors(u) {
+			if _, ok := g.Nodes[v]; !ok {
+				continue
+			}
+			if onStack[v] {
+				cycle := stack[slices.Index(stack, v):]
+				exit := slices.ContainsFunc(cycle, func(n string) bool { return g.Nodes[n].Branch != nil || len(g.Conditions[n]) > 0 })
+				if !exit {
+					add(DiagError, DiagUnboundedCycle, u, v, "cycle %s -> %s has no Branch node or conditional edge to exit", strings.Join(cycle, " -> "), v)
+				}
+				continue
+			}
+			if !reached[v] {
+				dfs(v)
+			}
+		}
+		stack = stack[:len(stack)-1]
+		onStack[u] = false
+	}
+	dfs(start)
+
+	for _, name := range names {
+		if !reached[name] {
+			add(DiagWarning, DiagUnreachable, name, "", "node %s is not reachable from %s", name, start)
+		}
+	}
+	return start, ds
+}
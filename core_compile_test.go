@@ -0,0 +1,51 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func hasDiag(ds Diagnostics, code string) bool {
+	return slices.ContainsFunc(ds, func(d Diagnostic) bool { return d.Code == code })
+}
+
+func TestValidateReportsProblems(t *testing.T) {
+	g := NewDSLGraph()
+	g.AddNode("a", &Node{Execute: value(1)})
+	g.AddNode("b", &Node{})
+	g.AddNode("orphan", &Node{Execute: value(2)})
+	g.AddEdge("a", "b")
+	g.AddEdge("a", "missing")
+	g.start = "a"
+	ds := g.Validate()
+	for _, code := range []string{DiagMissingExecute, DiagMissingTarget, DiagUnreachable} {
+		if !hasDiag(ds, code) {
+			t.Errorf("missing diagnostic %s in %v", code, ds)
+		}
+	}
+	_, err := g.Compile()
+	var ce *CompileError
+	if !errors.As(err, &ce) {
+		t.Fatalf("want CompileError, got %v", err)
+	}
+}
+
+func TestCompiledGraphIgnoresLaterEdits(t *testing.T) {
+	g := NewDSLGraph()
+	g.StartWith("a", value(1)).Then("b", value(2))
+	c, err := g.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Nodes["b"].Execute = failing("edited")
+	g.AddEdge("b", "c")
+	res, err := c.Run(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.State["b"].Data != 2 {
+		t.Fatalf("compiled graph saw later edit: %v", res.State["b"].Data)
+	}
+}
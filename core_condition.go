@@ -0,0 +1,128 @@
+package workflow
+
+import (
+	"fmt"
+	"github.com/ninenhan/go-workflow/flow"
+	"github.com/ninenhan/go-workflow/fn"
+	"maps"
+	"slices"
+)
+
+// EdgeCondition 边上的条件，When 与 Expr 二选一。
+// 节点的出边带条件时，无条件的边总会执行，条件成立的边执行，都不成立时执行 Default 边，其余边视为被分支跳过。
+type EdgeCondition struct {
+	When *flow.Condition `json:"when,omitempty"`
+	// Expr govaluate 表达式，变量为节点名（值为其 Data），嵌套字段用 [node.field] 引用
+	Expr    string `json:"expr,omitempty"`
+	Default bool   `json:"default,omitempty"`
+}
+
+// AddConditionalEdge 添加带条件的边
+func (g *Graph) AddConditionalEdge(from, to string, cond EdgeCondition) {
+	g.AddEdge(from, to)
+	if g.Conditions == nil {
+		g.Conditions = map[string]map[string]EdgeCondition{}
+	}
+	if g.Conditions[from] == nil {
+		g.Conditions[from] = map[string]EdgeCondition{}
+	}
+	g.Conditions[from][to] = cond
+}
+
+// conditionModel 条件计算使用的数据：节点名 -> Data，map 类型的 Data 额外展开为 "节点名.字段"
+func conditionModel(state ContextMap) map[string]any {
+	model := make(map[string]any, len(state))
+	var flatten func(prefix string, v any)
+	flatten = func(prefix string, v any) {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return
+		}
+		for k, item := range m {
+			key := prefix + "." + k
+			if _, exists := model[key]; !exists {
+				model[key] = item
+			}
+			flatten(key, item)
+		}
+	}
+	for name, r := range state {
+		if r == nil {
+			continue
+		}
+		model[name] = r.Data
+	}
+	for name, r := range state {
+		if r != nil {
+			flatten(name, r.Data)
+		}
+	}
+	return model
+}
+
+func (c EdgeCondition) match(model map[string]any) (bool, error) {
+	switch {
+	case c.When != nil:
+		return flow.MatchCondition(*c.When, model)
+	case c.Expr != "":
+		return fn.EvalBool(c.Expr, model)
+	}
+	return false, nil
+}
+
+// route 计算带条件出边的节点选择的下游
+func (g *Graph) route(name string, state ContextMap) ([]string, error) {
+	conds := g.Conditions[name]
+	model := conditionModel(state)
+	var next, defaults []string
+	matched := false
+	for _, to := range g.Edges[name] {
+		cond, ok := conds[to]
+		switch {
+		case !ok:
+			next = append(next, to)
+		case cond.Default:
+			defaults = append(defaults, to)
+		default:
+			ok, err := cond.match(model)
+			if err != nil {
+				return nil, fmt.Errorf("condition %s -> %s: %w", name, to, err)
+			}
+			if ok {
+				matched = true
+				next = append(next, to)
+			}
+		}
+	}
+	if !matched {
+		next = append(next, defaults...)
+	}
+	return next, nil
+}
+
+// validateConditions 边条件的编译期检查
+func (g *Graph) validateConditions(add func(level DiagnosticLevel, code, node, target, format string, args ...any)) {
+	for _, from := range slices.Sorted(maps.Keys(g.Conditions)) {
+		conds := g.Conditions[from]
+		if node := g.Nodes[from]; node != nil && node.Branch != nil && len(conds) > 0 {
+			add(DiagError, DiagInvalidCondition, from, "", "node %s has both Branch and conditional edges", from)
+		}
+		for _, to := range slices.Sorted(maps.Keys(conds)) {
+			cond := conds[to]
+			if !slices.Contains(g.Edges[from], to) {
+				add(DiagError, DiagInvalidCondition, from, to, "condition %s -> %s has no matching edge", from, to)
+				continue
+			}
+			switch {
+			case cond.When != nil && cond.Expr != "":
+				add(DiagError, DiagInvalidCondition, from, to, "condition %s -> %s sets both When and Expr", from, to)
+			case cond.Expr != "":
+				if err := fn.CompileExpr(cond.Expr); err != nil {
+					add(DiagError, DiagInvalidCondition, from, to, "condition %s -> %s: %v", from, to, err)
+				}
+			case cond.When == nil && !cond.Default:
+				add(DiagError, DiagInvalidCondition, from, to, "condition %s -> %s is empty", from, to)
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ninenhan/go-workflow/flow"
+)
+
+func conditionalGraph(score any, cond EdgeCondition) *Graph {
+	g := NewDSLGraph()
+	g.StartWith("a", value(map[string]any{"score": score}))
+	g.AddNode("hi", &Node{Execute: value("hi")})
+	g.AddNode("lo", &Node{Execute: value("lo")})
+	g.AddConditionalEdge("a", "hi", cond)
+	g.AddConditionalEdge("a", "lo", EdgeCondition{Default: true})
+	return g
+}
+
+func TestConditionalEdges(t *testing.T) {
+	cases := []struct {
+		name  string
+		score any
+		cond  EdgeCondition
+		want  string
+	}{
+		{"numeric GT", 5, EdgeCondition{When: &flow.Condition{Key: "a.score", Operator: flow.GT.Value, Value: 3}}, "hi"},
+		{"numeric GT from JSON string", "5", EdgeCondition{When: &flow.Condition{Key: "a.score", Operator: flow.GT.Value, Value: "3"}}, "hi"},
+		{"numeric GT not met", 2, EdgeCondition{When: &flow.Condition{Key: "a.score", Operator: flow.GT.Value, Value: 3}}, "lo"},
+		{"default EQ", 3, EdgeCondition{When: &flow.Condition{Key: "a.score", Value: "3"}}, "hi"},
+		{"expression", 5, EdgeCondition{Expr: "[a.score] > 3"}, "hi"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := conditionalGraph(tc.score, tc.cond).RunWithDSL(context.Background(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			other := map[string]string{"hi": "lo", "lo": "hi"}[tc.want]
+			if _, ok := res.State[tc.want]; !ok {
+				t.Fatalf("edge to %s not taken", tc.want)
+			}
+			if _, ok := res.State[other]; ok {
+				t.Fatalf("edge to %s taken", other)
+			}
+		})
+	}
+}
+
+func TestInvalidConditionIsReported(t *testing.T) {
+	g := conditionalGraph(1, EdgeCondition{Expr: "a >"})
+	if !hasDiag(g.Validate(), DiagInvalidCondition) {
+		t.Fatal("invalid expression was not reported")
+	}
+}
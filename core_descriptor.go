@@ -0,0 +1,99 @@
+package workflow
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/ninenhan/go-workflow/flow"
+)
+
+var unitMetaType = reflect.TypeOf(Unit{})
+
+// Describe 生成单元的描述，ref 规则同 Find；ExecutableUnit 的显示名默认取 Unit.DisplayName
+func (r *Registry) Describe(ref string) (flow.UnitDescriptor, bool) {
+	resolved, entry, ok := r.resolve(ref)
+	if !ok {
+		return flow.UnitDescriptor{}, false
+	}
+	return r.describe(resolved, entry), true
+}
+
+func (r *Registry) describe(ref UnitRef, entry registryEntry) flow.UnitDescriptor {
+	key := ref.Name
+	if entry.version.n > 0 {
+		key = ref.String()
+	}
+	var d flow.UnitDescriptor
+	if entry.phase {
+		d, _ = r.phases.Describe(key)
+	} else if unit, err := entry.newUnit(); err == nil {
+		d = flow.NewUnitDescriptor(ref.Name, flow.KindNode, unit, unitMetaType)
+		if meta := unit.GetUnitMeta(); meta != nil && meta.DisplayName != "" && d.DisplayName == ref.Name {
+			d.DisplayName = meta.DisplayName
+		}
+	}
+	d.Name = ref.Name
+	if d.DisplayName == key {
+		d.DisplayName = ref.Name
+	}
+	if entry.version.n > 0 {
+		d.Version = entry.version.String()
+	}
+	return d
+}
+
+// ListUnits 返回所有已注册单元（含各个版本）的描述，按名称、版本排序。
+// 注册表中的单元都可以在 Graph 与 Pipeline 中使用，Kind 表示单元原本实现的接口；
+// 直接注册到 Phases 的 PhaseUnit 也会列出
+func (r *Registry) ListUnits() []flow.UnitDescriptor {
+	type item struct {
+		ref   UnitRef
+		entry registryEntry
+	}
+	var items []item
+	keys := map[string]bool{}
+	r.mu.RLock()
+	for name, entries := range r.units {
+		for _, entry := range entries {
+			ref := UnitRef{Name: name}
+			if entry.version.n > 0 {
+				ref.Version = entry.version.String()
+			}
+			items = append(items, item{ref, entry})
+			keys[ref.String()] = true
+		}
+	}
+	r.mu.RUnlock()
+
+	var list []flow.UnitDescriptor
+	for _, it := range items {
+		list = append(list, r.describe(it.ref, it.entry))
+	}
+	for _, name := range r.phases.Names() {
+		if keys[name] {
+			continue
+		}
+		if d, ok := r.phases.Describe(name); ok {
+			list = append(list, d)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Name != list[j].Name {
+			return list[i].Name < list[j].Name
+		}
+		vi, _ := parseUnitVersion(list[i].Version)
+		vj, _ := parseUnitVersion(list[j].Version)
+		return vi.less(vj)
+	})
+	return list
+}
+
+// DescribeUnit 在 DefaultRegistry 中生成单元描述
+func DescribeUnit(ref string) (flow.UnitDescriptor, bool) {
+	return DefaultRegistry.Describe(ref)
+}
+
+// ListUnits 列出 DefaultRegistry 中的单元
+func ListUnits() []flow.UnitDescriptor {
+	return DefaultRegistry.ListUnits()
+}
@@ -0,0 +1,46 @@
+package workflow
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ninenhan/go-workflow/flow"
+)
+
+func TestDescribeUnitParams(t *testing.T) {
+	r := newTestRegistry(t)
+	d, ok := r.Describe("test/greet")
+	if !ok {
+		t.Fatal("unit not described")
+	}
+	if d.Name != "test/greet" || d.Version != "1.0.0" || d.Kind != flow.KindNode {
+		t.Fatalf("unexpected descriptor %+v", d)
+	}
+	props := d.Params["properties"].(map[string]any)
+	if _, ok := props["unit_name"]; ok {
+		t.Fatal("unit meta fields leaked into params")
+	}
+	greeting := props["greeting"].(map[string]any)
+	if greeting["type"] != "string" || greeting["description"] != "问候语" {
+		t.Fatalf("unexpected schema %v", greeting)
+	}
+	if !slices.Equal(d.Params["required"].([]string), []string{"greeting"}) {
+		t.Fatalf("unexpected required %v", d.Params["required"])
+	}
+}
+
+func TestListUnitsSortedByNameAndVersion(t *testing.T) {
+	r := newTestRegistry(t)
+	if err := r.RegisterUnit("test/greet@0.9.0", &greetUnit{}); err != nil {
+		t.Fatal(err)
+	}
+	var versions []string
+	for _, d := range r.ListUnits() {
+		if d.Name == "test/greet" {
+			versions = append(versions, d.Version)
+		}
+	}
+	if !slices.Equal(versions, []string{"0.9.0", "1.0.0"}) {
+		t.Fatalf("unexpected versions %v", versions)
+	}
+}
@@ -2,11 +2,19 @@ package workflow
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"github.com/ninenhan/go-workflow/flow"
 	"github.com/ninenhan/go-workflow/fn"
-	"sync"
+	"github.com/ninenhan/go-workflow/store"
+	"time"
 )
 
+var (
+	ErrNodeTimeout  = errors.New("node timeout")
+	ErrRunCancelled = errors.New("run cancelled")
+)
+
+// ContextMap 节点名 -> 执行结果。运行期间由 State 负责同步访问
 type ContextMap map[string]*ExecutionResult
 
 type Unit struct {
@@ -35,6 +43,13 @@ type ExecutionResult struct {
 	Stream   bool   `json:"stream,omitempty"`
 	Raw      any    `json:"raw,omitempty"`
 	Error    string `json:"error,omitempty"`
+	// Branches 并行节点各分支的执行结果
+	Branches []BranchOutcome `json:"branches,omitempty"`
+	Attempts int             `json:"attempts,omitempty"` // 实际尝试次数（含重试）
+	// Interrupt 非空表示节点请求人工介入，运行挂起
+	Interrupt *InterruptRequest `json:"interrupt,omitempty"`
+	// Control LoopCond 循环中的 break/continue
+	Control LoopControl `json:"control,omitempty"`
 }
 
 func SimpleResult(data any) *ExecutionResult {
@@ -53,19 +68,65 @@ type Node struct {
 	Input        *Input
 	Execute      NodeFunc
 	Branch       BranchFunc            // 可选分支函数
-	Parallel     bool                  // 是否并行节点
-	LoopCond     func(ContextMap) bool // 可选循环条件
+	Parallel     bool                  // 兼容字段：多条出边的节点总是并发执行下游
+	LoopCond     func(ContextMap) bool // 可选循环条件，每轮的序号见 LoopKey(name) 或 LoopIteration(ctx)
 	ExportFields []string              // 导出字段，用于供下游引用
+	Join         JoinMode              // 多入边时的汇聚方式，默认 JoinAll
+	JoinN        int                   // Join 为 JoinNofM 时需要完成的上游数量
+	UnitID       string                // 由注册单元构建时的单元 ID
+	Retry        *RetryPolicy          // 失败重试策略
+	Timeout      time.Duration         // 节点超时（含所有重试），0 不限制
+	// BranchTargets Branch 可能返回的节点，用于编译期校验；声明后运行时返回其他节点视为错误
+	BranchTargets []string
+	// BranchLabels 分支边的标签：目标节点 -> 分支名，仅用于展示
+	BranchLabels map[string]string
+	// Subgraph 未设置 Execute 时执行该子图
+	Subgraph *Subgraph
+	// ForEach 未设置 Execute 时对列表逐项执行
+	ForEach *ForEach
+	// MaxIterations LoopCond 循环的轮数上限，0 取 DefaultMaxIterations，<0 不限制
+	MaxIterations int
+	// Accumulate 为 true 时循环结果的 Data 为各轮 Data 组成的列表，而不是只保留最后一轮
+	Accumulate bool
+	// Params 单元参数，原样保存以便导出
+	Params map[string]any
+	// Layout 编辑器的布局信息（坐标、折叠状态等），引擎不使用
+	Layout map[string]any
 }
 
 type Graph struct {
 	Nodes map[string]*Node
 	Edges map[string][]string
-	Hooks struct {
+	// Conditions 边上的条件：起点 -> 终点 -> 条件
+	Conditions map[string]map[string]EdgeCondition
+	Hooks      struct {
 		Before func(name string, state ContextMap)
 		After  func(name string, result any, err error, state ContextMap)
 	}
-	start string
+	FailFast       bool          // 任一节点失败时取消其余节点
+	MaxConcurrency int           // 同时执行的节点数上限，<=0 不限制
+	Timeout        time.Duration // 整个运行的超时，0 不限制
+	// Checkpoints 设置后每个节点完成都会保存检查点，可通过 Resume 恢复
+	Checkpoints store.CheckpointStore
+	// Events 设置后每次运行的事件发布到以运行 ID 命名的 topic
+	Events    *fn.EventBus[flow.Event]
+	Listeners []flow.Listener
+	// EventRetention 运行结束后 topic 的保留时长，0 使用 flow.DefaultEventRetention，小于 0 不自动删除（调用方 RemoveTopic）
+	EventRetention time.Duration
+	// Layout 编辑器的画布信息，引擎不使用
+	Layout map[string]any
+	// Registry 解析 UnitID 使用的注册表，为空时使用 DefaultRegistry
+	Registry  *Registry
+	start     string
+	cursor    []string    // DSL 当前的接续节点
+	dslErrors Diagnostics // DSL 构建时发现的问题，编译时报告
+}
+
+func (g *Graph) registry() *Registry {
+	if g.Registry != nil {
+		return g.Registry
+	}
+	return DefaultRegistry
 }
 
 func (g *Graph) AddNode(name string, node *Node) {
@@ -84,73 +145,13 @@ func (g *Graph) AddBranch(name string, exec NodeFunc, branch BranchFunc) {
 	g.Nodes[name] = &Node{Name: name, Execute: exec, Branch: branch}
 }
 
-func (g *Graph) Run(ctx context.Context, start string, state ContextMap) error {
-	var exec func(string) error
-	exec = func(curr string) error {
-		if curr == "END" {
-			return nil
-		}
-
-		node, ok := g.Nodes[curr]
-		if !ok {
-			return fmt.Errorf("node %s not found", curr)
-		}
-
-		if g.Hooks.Before != nil {
-			g.Hooks.Before(curr, state)
-		}
-
-		var result *ExecutionResult
-		var err error
-		for {
-			result, err = node.Execute(ctx, state, node)
-			if err != nil {
-				state[curr] = &ExecutionResult{
-					Error: err.Error(),
-				}
-				return fmt.Errorf("exec %s failed: %w", curr, err)
-			}
-
-			state[curr] = result
-
-			if node.LoopCond == nil || !node.LoopCond(state) {
-				break
-			}
-		}
-
-		if g.Hooks.After != nil {
-			g.Hooks.After(curr, result, err, state)
-		}
-
-		// 分支跳转
-		var next string
-		if node.Branch != nil {
-			next = node.Branch(result, state)
-		} else {
-			nexts := g.Edges[curr]
-			if len(nexts) == 0 {
-				return nil
-			}
-			if node.Parallel {
-				// 并行执行所有子节点
-				var wg sync.WaitGroup
-				for _, nxt := range nexts {
-					wg.Add(1)
-					go func(n string) {
-						defer wg.Done()
-						_ = exec(n)
-					}(nxt)
-				}
-				wg.Wait()
-				return nil
-			}
-			next = nexts[0]
-		}
-
-		return exec(next)
+// Run 从 start 开始按拓扑顺序执行，无依赖的节点并发执行（受 MaxConcurrency 限制）
+func (g *Graph) Run(ctx context.Context, start string, initial ContextMap) (*RunResult, error) {
+	c, err := g.CompileFrom(start)
+	if err != nil {
+		return nil, err
 	}
-
-	return exec(start)
+	return c.Run(ctx, initial)
 }
 
 //
@@ -164,143 +165,43 @@ func NewDSLGraph() *Graph {
 	}
 }
 
-func (g *Graph) StartWith(name string, fn NodeFunc) *Graph {
-	g.start = name
-	g.Nodes[name] = &Node{Name: name, Execute: fn}
-	return g
-}
-
-func (g *Graph) Then(name string, fn NodeFunc) *Graph {
-	last := g.lastNode()
-	g.Nodes[name] = &Node{Name: name, Execute: fn}
-	g.Edges[last] = append(g.Edges[last], name)
-	return g
-}
-
-func (g *Graph) Branch(name string, fn NodeFunc, branch BranchFunc) *Graph {
-	last := g.lastNode()
-	g.Nodes[name] = &Node{Name: name, Execute: fn, Branch: branch}
-	g.Edges[last] = append(g.Edges[last], name)
-	return g
-}
-
-func (g *Graph) Loop(name string, fn NodeFunc, cond LoopCondFunc) *Graph {
-	last := g.lastNode()
-	g.Nodes[name] = &Node{Name: name, Execute: fn, LoopCond: cond}
-	g.Edges[last] = append(g.Edges[last], name)
-	return g
-}
-
-func (g *Graph) Parallel(name string, fns ...NodeFunc) *Graph {
-	last := g.lastNode()
-	for i, nodeFunc := range fns {
-		child := fmt.Sprintf("%s_p%d", name, i)
-		g.Nodes[child] = &Node{Name: child, Execute: nodeFunc, Parallel: true}
-		g.Edges[last] = append(g.Edges[last], child)
-	}
-	return g
-}
-
+// OnBefore 多次调用时按注册顺序依次执行
 func (g *Graph) OnBefore(fn func(string, ContextMap)) *Graph {
+	if prev := g.Hooks.Before; prev != nil {
+		g.Hooks.Before = func(name string, state ContextMap) {
+			prev(name, state)
+			fn(name, state)
+		}
+		return g
+	}
 	g.Hooks.Before = fn
 	return g
 }
 
+// OnAfter 多次调用时按注册顺序依次执行
 func (g *Graph) OnAfter(fn func(string, any, error, ContextMap)) *Graph {
+	if prev := g.Hooks.After; prev != nil {
+		g.Hooks.After = func(name string, result any, err error, state ContextMap) {
+			prev(name, result, err, state)
+			fn(name, result, err, state)
+		}
+		return g
+	}
 	g.Hooks.After = fn
 	return g
 }
 
-func (g *Graph) RunWithDSL(ctx context.Context, state ContextMap) error {
-	var exec func(name string) error
-
-	exec = func(curr string) error {
-		node, ok := g.Nodes[curr]
-		if !ok {
-			return fmt.Errorf("node %s not found", curr)
-		}
-		exported := make(map[string]any)
-		//state 为执行过的状态映射
-		//获取curr之前的nodes
-		for nodeName, result := range state {
-			node := g.Nodes[nodeName]
-			if node == nil || len(node.ExportFields) == 0 {
-				continue
-			}
-			if resultMap, ok := result.Data.(map[string]any); ok {
-				for _, field := range node.ExportFields {
-					if val, exists := resultMap[field]; exists {
-						exported[fmt.Sprintf("%s.%s", nodeName, field)] = val
-					}
-				}
-			}
-		}
-
-		if g.Hooks.Before != nil {
-			g.Hooks.Before(curr, state)
-		}
-
-		//批量处理input
-		if node.Input != nil && node.Input.Data != nil {
-			if node.Input.Slottable {
-				if inputData, ok := node.Input.Data.(string); ok {
-					//AUTO FILL : ExportFields
-					parsed, _ := fn.ParseTemplate(inputData)
-					rendered := fn.RenderTemplateStrictly(inputData, parsed, exported, false)
-					node.Input.Data = rendered
-					node.Input.Slottable = false
-				}
-			}
-		}
-
-		var result *ExecutionResult
-		var err error
-		for {
-			result, err = node.Execute(ctx, state, node)
-			if err != nil {
-				return err
-			}
-			state[curr] = result
-			if node.LoopCond == nil || !node.LoopCond(state) {
-				break
-			}
-		}
-
-		if g.Hooks.After != nil {
-			g.Hooks.After(curr, result, err, state)
-		}
-
-		if node.Branch != nil {
-			next := node.Branch(result, state)
-			return exec(next)
-		}
-
-		nexts := g.Edges[curr]
-		if node.Parallel {
-			var wg sync.WaitGroup
-			for _, n := range nexts {
-				wg.Add(1)
-				go func(n string) {
-					defer wg.Done()
-					_ = exec(n)
-				}(n)
-			}
-			wg.Wait()
-			return nil
-		} else if len(nexts) > 0 {
-			return exec(nexts[0])
-		}
-		return nil
-	}
-
-	return exec(g.start)
+// OnEvent 注册运行事件监听
+func (g *Graph) OnEvent(l flow.Listener) *Graph {
+	g.Listeners = append(g.Listeners, l)
+	return g
 }
 
-func (g *Graph) lastNode() string {
-	for k := range g.Nodes {
-		if len(g.Edges[k]) == 0 {
-			return k
-		}
+// RunWithDSL 以 DSL 起点（或唯一的无入边节点）运行；每次调用都会重新编译，Graph 本身不会被修改
+func (g *Graph) RunWithDSL(ctx context.Context, initial ContextMap) (*RunResult, error) {
+	c, err := g.Compile()
+	if err != nil {
+		return nil, err
 	}
-	return ""
+	return c.Run(ctx, initial)
 }
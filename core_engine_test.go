@@ -0,0 +1,95 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// value 返回固定数据的节点
+func value(data any) NodeFunc {
+	return func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+		return SimpleResult(data), nil
+	}
+}
+
+// failing 总是失败的节点
+func failing(msg string) NodeFunc {
+	return func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+		return nil, errors.New(msg)
+	}
+}
+
+// counter 记录每个节点的执行次数
+type counter struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func newCounter() *counter {
+	return &counter{calls: map[string]int{}}
+}
+
+func (c *counter) wrap(name string, f NodeFunc) NodeFunc {
+	return func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+		c.mu.Lock()
+		c.calls[name]++
+		c.mu.Unlock()
+		return f(ctx, state, self)
+	}
+}
+
+func (c *counter) get(name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[name]
+}
+
+func TestParallelErrorsAreAggregated(t *testing.T) {
+	g := NewDSLGraph()
+	g.StartWith("a", value(1))
+	g.Parallel("p", failing("left"), failing("right"), value(3)).Join("j", value(4))
+	_, err := g.RunWithDSL(context.Background(), nil)
+	var pe *ParallelError
+	if !errors.As(err, &pe) {
+		t.Fatalf("want ParallelError, got %v", err)
+	}
+	if pe.Node != "a" || len(pe.Branches) != 3 || len(pe.Failed()) != 2 {
+		t.Fatalf("unexpected aggregation: node=%s branches=%+v", pe.Node, pe.Branches)
+	}
+}
+
+func TestFailFastCancelsSiblings(t *testing.T) {
+	var finished atomic.Bool
+	slow := func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+		select {
+		case <-time.After(2 * time.Second):
+			finished.Store(true)
+			return SimpleResult("slow"), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	g := NewDSLGraph()
+	g.FailFast = true
+	g.StartWith("a", value(1))
+	g.Parallel("p", failing("boom"), slow).Join("j", value(2))
+	begin := time.Now()
+	res, err := g.RunWithDSL(context.Background(), nil)
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if time.Since(begin) > time.Second || finished.Load() {
+		t.Fatal("sibling branch was not cancelled")
+	}
+	var pe *ParallelError
+	if !errors.As(err, &pe) || len(pe.Failed()) != 1 {
+		t.Fatalf("want exactly one failed branch, got %v", err)
+	}
+	if _, ok := res.State["j"]; ok {
+		t.Fatal("join ran after fail-fast")
+	}
+}
@@ -0,0 +1,21 @@
+package workflow
+
+import (
+	"context"
+	"github.com/ninenhan/go-workflow/flow"
+)
+
+type emitterKey struct{}
+
+type nodeNameKey struct{}
+
+// EmitChunk 节点产生流式输出时调用，发布 stream_chunk 事件；不在引擎内运行或未订阅事件时忽略
+func EmitChunk(ctx context.Context, chunk any) {
+	e, _ := ctx.Value(emitterKey{}).(*flow.Emitter)
+	name, _ := ctx.Value(nodeNameKey{}).(string)
+	e.Emit(flow.Event{Type: flow.EventStreamChunk, Node: name, Data: chunk})
+}
+
+func (s *scheduler) emit(evt flow.Event) {
+	s.events.Emit(evt)
+}
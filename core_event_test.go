@@ -0,0 +1,97 @@
+package workflow
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ninenhan/go-workflow/flow"
+	"github.com/ninenhan/go-workflow/fn"
+)
+
+func TestRunEventsAndTopicExpiry(t *testing.T) {
+	bus := fn.NewBus[flow.Event]()
+	var mu sync.Mutex
+	var types []flow.EventType
+	g := NewDSLGraph()
+	g.Events = bus
+	g.EventRetention = 10 * time.Millisecond
+	g.OnEvent(func(evt flow.Event) {
+		mu.Lock()
+		types = append(types, evt.Type)
+		mu.Unlock()
+	})
+	g.StartWith("a", value(1)).Then("b", failing("boom"))
+	sub := bus.GetOrCreateTopic("run-e", flow.DefaultEventCache).Subscribe(64)
+	res, _ := g.RunWithDSL(WithRunID(context.Background(), "run-e"), nil)
+	if res.RunID != "run-e" {
+		t.Fatalf("unexpected run id %s", res.RunID)
+	}
+	mu.Lock()
+	first, last := types[0], types[len(types)-1]
+	mu.Unlock()
+	if first != flow.EventRunStarted || last != flow.EventRunFinished {
+		t.Fatalf("unexpected event order %v", types)
+	}
+	var got []flow.EventType
+	for evt := range sub.Ch { // topic 到期删除后 channel 关闭
+		got = append(got, evt.Data.Type)
+	}
+	if len(got) != len(types) {
+		t.Fatalf("subscriber saw %v, listener saw %v", got, types)
+	}
+	if bus.GetTopic("run-e") != nil {
+		t.Fatal("topic was not removed after retention")
+	}
+}
+
+func TestNegativeRetentionKeepsTopic(t *testing.T) {
+	bus := fn.NewBus[flow.Event]()
+	g := NewDSLGraph()
+	g.Events = bus
+	g.EventRetention = -1
+	g.StartWith("a", value(1))
+	res, err := g.RunWithDSL(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if bus.GetTopic(res.RunID) == nil {
+		t.Fatal("topic removed despite negative retention")
+	}
+	bus.RemoveTopic(res.RunID)
+}
+
+func TestAbandonedNodeEventsDoNotKeepTopic(t *testing.T) {
+	bus := fn.NewBus[flow.Event]()
+	release := make(chan struct{})
+	done := make(chan struct{})
+	g := NewDSLGraph()
+	g.Events = bus
+	g.EventRetention = 50 * time.Millisecond
+	g.StartWith("a", func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+		<-release
+		EmitChunk(ctx, "late")
+		close(done)
+		return SimpleResult(1), nil
+	})
+	g.Nodes["a"].Timeout = 10 * time.Millisecond
+	res, err := g.RunWithDSL(WithRunID(context.Background(), "run-late"), nil)
+	if err == nil {
+		t.Fatal("want timeout")
+	}
+	close(release)
+	<-done
+	history := bus.GetTopic(res.RunID).Snapshot()
+	if last := history[len(history)-1].Data.Type; last != flow.EventRunFinished {
+		t.Fatalf("event published after run_finished: %s", last)
+	}
+	deadline := time.Now().Add(time.Second)
+	for bus.GetTopic(res.RunID) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("topic was not removed after retention")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,138 @@
+package workflow
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/ninenhan/go-workflow/flow"
+)
+
+// Diagram 将图转换为流程图；overlay 非空时按运行结果标注节点状态与耗时
+func (g *Graph) Diagram(overlay *RunResult) *flow.Diagram {
+	names := make([]string, 0, len(g.Nodes))
+	for name := range g.Nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// 起点排在最前，便于阅读
+	if g.start != "" {
+		for i, name := range names {
+			if name == g.start {
+				names = append(append([]string{name}, names[:i]...), names[i+1:]...)
+				break
+			}
+		}
+	}
+
+	statuses := map[string]flow.JobStatus{}
+	durations := map[string]time.Duration{}
+	if overlay != nil {
+		for _, entry := range overlay.Trace {
+			statuses[entry.Node] = entry.Status
+			durations[entry.Node] += entry.Duration
+		}
+	}
+
+	d := &flow.Diagram{}
+	for _, name := range names {
+		node := g.Nodes[name]
+		d.Nodes = append(d.Nodes, flow.DiagramNode{
+			ID:       name,
+			Label:    g.displayName(name, node),
+			Shape:    g.shape(name, node),
+			Status:   statuses[name],
+			Duration: durations[name],
+		})
+		if node.LoopCond != nil {
+			d.Edges = append(d.Edges, flow.DiagramEdge{From: name, To: name, Label: "loop", Dashed: true})
+		}
+		for _, to := range g.Edges[name] {
+			if _, ok := g.Nodes[to]; !ok {
+				continue
+			}
+			d.Edges = append(d.Edges, flow.DiagramEdge{From: name, To: to, Label: g.edgeLabel(name, to)})
+		}
+		// 只有条件、没有普通边的目标也要画出来
+		targets := make([]string, 0, len(g.Conditions[name]))
+		for to := range g.Conditions[name] {
+			if _, ok := g.Nodes[to]; ok && !slices.Contains(g.Edges[name], to) {
+				targets = append(targets, to)
+			}
+		}
+		sort.Strings(targets)
+		for _, to := range targets {
+			d.Edges = append(d.Edges, flow.DiagramEdge{From: name, To: to, Label: g.edgeLabel(name, to)})
+		}
+	}
+	return d
+}
+
+// Mermaid 渲染为 Mermaid flowchart，overlay 可为 nil
+func (g *Graph) Mermaid(overlay *RunResult) string {
+	return g.Diagram(overlay).Mermaid()
+}
+
+// DOT 渲染为 Graphviz DOT，overlay 可为 nil
+func (g *Graph) DOT(overlay *RunResult) string {
+	return g.Diagram(overlay).DOT()
+}
+
+func (c *CompiledGraph) Diagram(overlay *RunResult) *flow.Diagram {
+	return c.graph.Diagram(overlay)
+}
+
+func (c *CompiledGraph) Mermaid(overlay *RunResult) string {
+	return c.graph.Mermaid(overlay)
+}
+
+func (c *CompiledGraph) DOT(overlay *RunResult) string {
+	return c.graph.DOT(overlay)
+}
+
+// displayName 注册单元的显示名优先，其次为节点名
+func (g *Graph) displayName(name string, node *Node) string {
+	if node.UnitID != "" {
+		if unit, ok := g.registry().Find(node.UnitID); ok {
+			if meta := unit.GetUnitMeta(); meta != nil && meta.DisplayName != "" {
+				return meta.DisplayName
+			}
+		}
+	}
+	if node.Name != "" {
+		return node.Name
+	}
+	return name
+}
+
+func (g *Graph) shape(name string, node *Node) flow.DiagramShape {
+	switch {
+	case node.Subgraph != nil:
+		return flow.ShapeSubgraph
+	case node.ForEach != nil:
+		return flow.ShapeMap
+	case node.LoopCond != nil:
+		return flow.ShapeLoop
+	case node.Branch != nil || len(g.Conditions[name]) > 0:
+		return flow.ShapeDecision
+	}
+	return flow.ShapeBox
+}
+
+// edgeLabel 条件边显示条件，Branch 构建器声明的边显示分支名
+func (g *Graph) edgeLabel(from, to string) string {
+	if cond, ok := g.Conditions[from][to]; ok {
+		switch {
+		case cond.Default:
+			return "default"
+		case cond.Expr != "":
+			return cond.Expr
+		case cond.When != nil:
+			return cond.When.String()
+		}
+	}
+	if node := g.Nodes[from]; node != nil {
+		return node.BranchLabels[to]
+	}
+	return ""
+}
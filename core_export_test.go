@@ -0,0 +1,131 @@
+package workflow
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ninenhan/go-workflow/flow"
+)
+
+func TestDiagramWithRunOverlay(t *testing.T) {
+	g := NewDSLGraph()
+	g.StartWith("a", value(1)).
+		Branch("route", value(nil), func(*ExecutionResult, ContextMap) string { return "yes" }).
+		Case("yes", "ok", value("ok")).
+		Case("no", "ko", value("ko")).
+		End()
+	res, err := g.RunWithDSL(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := g.Diagram(res)
+	if d.Nodes[0].ID != "a" {
+		t.Fatalf("start node should come first, got %s", d.Nodes[0].ID)
+	}
+	statuses := map[string]flow.JobStatus{}
+	for _, n := range d.Nodes {
+		statuses[n.ID] = n.Status
+	}
+	if statuses["ok"] != flow.TaskCompleted || statuses["ko"] != "" {
+		t.Fatalf("unexpected overlay %v", statuses)
+	}
+	labels := map[string]string{}
+	for _, e := range d.Edges {
+		labels[e.From+"->"+e.To] = e.Label
+	}
+	if labels["route->ok"] != "yes" || labels["route->ko"] != "no" {
+		t.Fatalf("unexpected edge labels %v", labels)
+	}
+	if m := g.Mermaid(nil); !strings.HasPrefix(m, "flowchart") {
+		t.Fatalf("unexpected mermaid output:\n%s", m)
+	}
+	if dot := g.DOT(res); !strings.HasPrefix(dot, "digraph") {
+		t.Fatalf("unexpected DOT output:\n%s", dot)
+	}
+}
+
+// notePhase 只用于展开流程图的 PhaseUnit
+type notePhase struct {
+	flow.BaseUnit
+}
+
+func (u *notePhase) Execute(ctx *flow.PipelineContext, input *flow.Input) (*flow.Output, error) {
+	return nil, nil
+}
+
+func note(id string) *notePhase {
+	return &notePhase{BaseUnit: flow.BaseUnit{ID: id, UnitName: "Note"}}
+}
+
+func diagramPipeline() *flow.Pipeline {
+	branch := &flow.IfUnit{
+		BaseUnit:         flow.BaseUnit{ID: "check", UnitName: "IfUnit"},
+		IfCondition:      flow.Condition{Key: "score", Operator: flow.GT.Value, Value: 90},
+		ElseIfConditions: []flow.Condition{{Label: "passed"}},
+		IfUnits:          []flow.PhaseUnit{note("excellent")},
+		ElseIfUnits:      [][]flow.PhaseUnit{{note("pass")}},
+	}
+	loop := &flow.WhileUnit{
+		BaseUnit:  flow.BaseUnit{ID: "retry", UnitName: "WhileUnit"},
+		Condition: flow.Condition{Script: "attempts < 3"},
+		Units:     []flow.PhaseUnit{note("call"), note("wait")},
+	}
+	return flow.NewPipeline([]flow.PhaseUnit{note("start"), branch, loop, note("done")})
+}
+
+func TestPipelineDiagramGolden(t *testing.T) {
+	p := diagramPipeline()
+	if got := p.Mermaid(); got != pipelineMermaid {
+		t.Errorf("mermaid output changed:\n%s", got)
+	}
+	if got := p.DOT(); got != pipelineDOT {
+		t.Errorf("DOT output changed:\n%s", got)
+	}
+}
+
+// pipelineMermaid IfUnit 的各分支汇合到下一个单元，WhileUnit 的循环体以虚线回到循环
+const pipelineMermaid = `flowchart TD
+    n0["Note<br/>start"]
+    n1{"IfUnit<br/>check"}
+    n2["Note<br/>excellent"]
+    n3["Note<br/>pass"]
+    n4{{"WhileUnit<br/>retry"}}
+    n5["Note<br/>call"]
+    n6["Note<br/>wait"]
+    n7["Note<br/>done"]
+    n0 --> n1
+    n1 -->|"score GT 90"| n2
+    n1 -->|"passed"| n3
+    n2 --> n4
+    n3 --> n4
+    n1 -->|"else"| n4
+    n4 -->|"attempts < 3"| n5
+    n5 --> n6
+    n6 -.-> n4
+    n4 -->|"exit"| n7
+`
+
+const pipelineDOT = `digraph workflow {
+    rankdir=TB;
+    node [shape=box, style=rounded];
+    n0 [label="Note\nstart"];
+    n1 [label="IfUnit\ncheck", shape=diamond];
+    n2 [label="Note\nexcellent"];
+    n3 [label="Note\npass"];
+    n4 [label="WhileUnit\nretry", shape=hexagon];
+    n5 [label="Note\ncall"];
+    n6 [label="Note\nwait"];
+    n7 [label="Note\ndone"];
+    n0 -> n1;
+    n1 -> n2 [label="score GT 90"];
+    n1 -> n3 [label="passed"];
+    n2 -> n4;
+    n3 -> n4;
+    n1 -> n4 [label="else"];
+    n4 -> n5 [label="attempts < 3"];
+    n5 -> n6;
+    n6 -> n4 [style=dashed];
+    n4 -> n7 [label="exit"];
+}
+`
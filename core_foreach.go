@@ -0,0 +1,169 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ninenhan/go-workflow/fn"
+	"reflect"
+	"strconv"
+)
+
+// ForEach 对上游产出的列表逐项执行同一个节点或子图。
+// Items 为 fn.GetValue 路径，第一段是节点名，其余部分作用在该节点结果的 Data 上，如 "search.urls"、"search.hits[0].links"。
+// 每一项执行时状态中 ItemKey（默认 "item"）为当前元素，也可通过 ForEachItem(ctx) 取得。
+type ForEach struct {
+	Items       string    `json:"items"`
+	ItemKey     string    `json:"item_key,omitempty"`
+	Body        NodeFunc  `json:"-"`                     // 与 Unit、Subgraph 三选一
+	Unit        string    `json:"unit,omitempty"`        // 已注册的 unit
+	Subgraph    *Subgraph `json:"subgraph,omitempty"`    // 子图运行 ID 为 "父运行 ID/节点名[下标]"
+	Concurrency int       `json:"concurrency,omitempty"` // 同时执行的项数，<=0 时逐项执行
+	// ContinueOnError 为 false 时任一项失败即节点失败；为 true 时失败记录在对应项中
+	ContinueOnError bool      `json:"continue_on_error,omitempty"`
+	registry        *Registry // 编译时设置为所在图的注册表
+}
+
+// ForEachOutcome 单项的执行结果
+type ForEachOutcome struct {
+	Index int    `json:"index"`
+	Data  any    `json:"data,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type forEachItemKey struct{}
+
+type forEachItem struct {
+	index int
+	item  any
+}
+
+// ForEachItem ForEach 的 body 内取得当前元素及其下标
+func ForEachItem(ctx context.Context) (index int, item any, ok bool) {
+	v, ok := ctx.Value(forEachItemKey{}).(forEachItem)
+	return v.index, v.item, ok
+}
+
+func (f *ForEach) body(r *Registry) (NodeFunc, error) {
+	switch {
+	case f.Body != nil:
+		return f.Body, nil
+	case f.Unit != "":
+		if r == nil {
+			r = DefaultRegistry
+		}
+		unit, ok := r.Find(f.Unit)
+		if !ok {
+			return nil, fmt.Errorf("unit %s is not registered", f.Unit)
+		}
+		return unit.Execute, nil
+	case f.Subgraph != nil:
+		if _, err := f.Subgraph.resolve(); err != nil {
+			return nil, err
+		}
+		return f.Subgraph.Execute, nil
+	}
+	return nil, errors.New("for-each has no body")
+}
+
+// resolveItems 按路径从状态中取出列表
+func resolveItems(state ContextMap, expr string) ([]any, error) {
+	path := fn.ParsePathExpr(expr)
+	if len(path) == 0 {
+		return nil, fmt.Errorf("invalid items path %q", expr)
+	}
+	name, _ := path[0].(string)
+	r, ok := state[name]
+	if !ok || r == nil {
+		return nil, fmt.Errorf("items path %q: node %s has no result", expr, name)
+	}
+	value := r.Data
+	if len(path) > 1 {
+		value = fn.GetByPath(value, path[1:])
+		if value == nil {
+			// 结构体或具体类型的切片先转为通用的 map/[]any 再取值
+			var generic any
+			if data, err := json.Marshal(r.Data); err == nil && json.Unmarshal(data, &generic) == nil {
+				value = fn.GetByPath(generic, path[1:])
+			}
+		}
+	}
+	if value == nil {
+		return nil, fmt.Errorf("items path %q resolved to nothing", expr)
+	}
+	if items, ok := value.([]any); ok {
+		return items, nil
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("items path %q is %T, not a list", expr, value)
+	}
+	items := make([]any, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items, nil
+}
+
+// Execute 结果的 Data 为按原顺序排列的各项 Data（失败项为 nil），Raw 为 []ForEachOutcome
+func (f *ForEach) Execute(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+	body, err := f.body(f.registry)
+	if err != nil {
+		return nil, err
+	}
+	items, err := resolveItems(state, f.Items)
+	if err != nil {
+		return nil, err
+	}
+	itemKey := f.ItemKey
+	if itemKey == "" {
+		itemKey = "item"
+	}
+	name, _ := ctx.Value(nodeNameKey{}).(string)
+	indexes := make([]int, len(items))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	out, errs := fn.RunParallel(ctx, indexes, f.Concurrency, strconv.Itoa, func(ctx context.Context, i int) (r *ExecutionResult, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("panic: %v", p)
+			}
+		}()
+		itemState := make(ContextMap, len(state)+1)
+		for k, v := range state {
+			itemState[k] = v
+		}
+		itemState[itemKey] = &ExecutionResult{NodeName: itemKey, Data: items[i]}
+		ctx = context.WithValue(ctx, forEachItemKey{}, forEachItem{index: i, item: items[i]})
+		ctx = context.WithValue(ctx, nodeNameKey{}, fmt.Sprintf("%s[%d]", name, i))
+		return body(ctx, itemState, self)
+	})
+
+	data := make([]any, len(items))
+	outcomes := make([]ForEachOutcome, len(items))
+	var failed []error
+	for i := range items {
+		outcomes[i].Index = i
+		r, done := out[strconv.Itoa(i)]
+		e := errs[i]
+		if e == nil && !done {
+			// 取消后未派发的项
+			e = ctx.Err()
+		}
+		if e != nil {
+			outcomes[i].Error = e.Error()
+			failed = append(failed, fmt.Errorf("item %d: %w", i, e))
+			continue
+		}
+		if r != nil {
+			data[i] = r.Data
+			outcomes[i].Data = r.Data
+		}
+	}
+	if len(failed) > 0 && !f.ContinueOnError {
+		return nil, errors.Join(failed...)
+	}
+	return &ExecutionResult{NodeName: name, Data: data, Raw: outcomes}, nil
+}
@@ -0,0 +1,61 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestForEachRunsBodyPerItem(t *testing.T) {
+	g := NewDSLGraph()
+	g.StartWith("search", value(map[string]any{"urls": []any{"a", "b", "c"}}))
+	g.AddNode("fetch", &Node{ForEach: &ForEach{
+		Items:       "search.urls",
+		Concurrency: 2,
+		Body: func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+			i, item, _ := ForEachItem(ctx)
+			if state["item"].Data != item {
+				return nil, errors.New("item key mismatch")
+			}
+			return SimpleResult(item.(string) + string(rune('0'+i))), nil
+		},
+	}})
+	g.AddEdge("search", "fetch")
+	res, err := g.RunWithDSL(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := res.State["fetch"].Data.([]any)
+	if len(got) != 3 || got[0] != "a0" || got[2] != "c2" {
+		t.Fatalf("unexpected outputs %v", got)
+	}
+}
+
+func TestForEachContinueOnError(t *testing.T) {
+	body := func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+		if _, item, _ := ForEachItem(ctx); item == 2 {
+			return nil, errors.New("bad item")
+		}
+		return SimpleResult("ok"), nil
+	}
+	for _, cont := range []bool{false, true} {
+		g := NewDSLGraph()
+		g.StartWith("list", value([]int{1, 2, 3}))
+		g.AddNode("each", &Node{ForEach: &ForEach{Items: "list", Body: body, ContinueOnError: cont}})
+		g.AddEdge("list", "each")
+		res, err := g.RunWithDSL(context.Background(), nil)
+		if !cont {
+			if err == nil {
+				t.Fatal("want failure without ContinueOnError")
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		outcomes := res.State["each"].Raw.([]ForEachOutcome)
+		if outcomes[1].Error == "" || outcomes[0].Error != "" || res.State["each"].Data.([]any)[1] != nil {
+			t.Fatalf("unexpected outcomes %+v", outcomes)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package workflow
+
+import (
+	"fmt"
+	"github.com/ninenhan/go-workflow/fn"
+)
+
+// instance 返回本次执行使用的节点：Input 可插槽时按当前状态渲染到节点副本中，
+// 图定义中的节点保持不变，同一个图可以被重复、并发地运行
+func (s *scheduler) instance(node *Node, state ContextMap) *Node {
+	if node.Input == nil || !node.Input.Slottable {
+		return node
+	}
+	text, ok := node.Input.Data.(string)
+	if !ok {
+		return node
+	}
+	//AUTO FILL : ExportFields
+	parsed, _ := fn.ParseTemplate(text)
+	n := *node
+	n.Input = &Input{
+		Data:     fn.RenderTemplateStrictly(text, parsed, s.exported(state), false),
+		DataType: node.Input.DataType,
+	}
+	return &n
+}
+
+// exported 已执行节点通过 ExportFields 导出的字段，模板中以 {{节点名.字段}} 引用
+func (s *scheduler) exported(state ContextMap) map[string]any {
+	exported := make(map[string]any)
+	for nodeName, result := range state {
+		node := s.g.Nodes[nodeName]
+		if node == nil || len(node.ExportFields) == 0 || result == nil {
+			continue
+		}
+		if resultMap, ok := result.Data.(map[string]any); ok {
+			// 模板按路径取值，同时保留扁平的 key
+			fields := map[string]any{}
+			for _, field := range node.ExportFields {
+				if val, exists := resultMap[field]; exists {
+					exported[fmt.Sprintf("%s.%s", nodeName, field)] = val
+					fields[field] = val
+				}
+			}
+			exported[nodeName] = fields
+		}
+	}
+	return exported
+}
@@ -0,0 +1,43 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestSlotInputsRenderPerRun(t *testing.T) {
+	g := NewDSLGraph()
+	g.StartWith("a", func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+		return SimpleResult(map[string]any{"name": state["who"].Data}), nil
+	}).Then("greet", func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+		return SimpleResult(self.Input.Data), nil
+	})
+	g.Nodes["a"].ExportFields = []string{"name"}
+	g.Nodes["greet"].Input = &Input{Data: "hello {{a.name}}", Slottable: true}
+	c, err := g.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			who := fmt.Sprint("user", i)
+			res, err := c.Run(context.Background(), ContextMap{"who": SimpleResult(who)})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if got := res.State["greet"].Data; got != "hello "+who {
+				t.Errorf("run %d rendered %v", i, got)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if g.Nodes["greet"].Input.Data != "hello {{a.name}}" {
+		t.Fatal("graph definition was modified by a run")
+	}
+}
@@ -0,0 +1,106 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var ErrInterrupted = errors.New("run interrupted")
+
+// InterruptRequest 节点请求人工介入
+type InterruptRequest struct {
+	Prompt any `json:"prompt,omitempty"` // 展示给人的内容，如待审批的数据
+}
+
+// PendingInterrupt 等待人工输入的节点
+type PendingInterrupt struct {
+	Node   string `json:"node"`
+	Token  string `json:"token"` // 传给 ResumeWithInput
+	Prompt any    `json:"prompt,omitempty"`
+}
+
+// InterruptedError 运行因人工介入挂起，状态已保存到检查点
+type InterruptedError struct {
+	RunID      string
+	Interrupts []PendingInterrupt
+}
+
+func (e *InterruptedError) Error() string {
+	nodes := make([]string, 0, len(e.Interrupts))
+	for _, it := range e.Interrupts {
+		nodes = append(nodes, it.Node)
+	}
+	return fmt.Sprintf("run %s interrupted at %s", e.RunID, strings.Join(nodes, ", "))
+}
+
+func (e *InterruptedError) Is(target error) bool {
+	return target == ErrInterrupted
+}
+
+// Interrupt 节点返回该结果即挂起运行，恢复时节点会重新执行，并可通过 ResumeInput 取得人工输入
+func Interrupt(prompt any) *ExecutionResult {
+	return &ExecutionResult{Interrupt: &InterruptRequest{Prompt: prompt}}
+}
+
+// InputKey 人工输入在 ContextMap 中的 key，供下游节点读取
+func InputKey(name string) string {
+	return name + "#input"
+}
+
+type resumeKey struct{}
+
+// ResumeInput 节点因 Interrupt 挂起后被恢复时，取得人工输入
+func ResumeInput(ctx context.Context) (any, bool) {
+	v, ok := ctx.Value(resumeKey{}).(*resumeInput)
+	if !ok {
+		return nil, false
+	}
+	return v.payload, true
+}
+
+type resumeInput struct {
+	payload any
+}
+
+func interruptToken(runID, node string) string {
+	return runID + "#" + node
+}
+
+// ParseInterruptToken 拆分出运行 ID 与节点名
+func ParseInterruptToken(token string) (runID, node string, err error) {
+	runID, node, ok := strings.Cut(token, "#")
+	if !ok || runID == "" || node == "" {
+		return "", "", fmt.Errorf("invalid interrupt token %q", token)
+	}
+	return runID, node, nil
+}
+
+// ResumeWithInput 注入人工输入并从挂起的节点继续运行
+func (g *Graph) ResumeWithInput(ctx context.Context, token string, payload any) (*RunResult, error) {
+	if g.Checkpoints == nil {
+		return nil, errors.New("graph has no checkpoint store")
+	}
+	runID, node, err := ParseInterruptToken(token)
+	if err != nil {
+		return nil, err
+	}
+	cp, err := LoadCheckpoint(ctx, g.Checkpoints, runID)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(cp.Interrupts, func(it PendingInterrupt) bool { return it.Node == node }) {
+		return nil, fmt.Errorf("run %s is not waiting for input at %s", runID, node)
+	}
+	c, err := g.CompileFrom(cp.Start)
+	if err != nil {
+		return nil, err
+	}
+	if cp.State == nil {
+		cp.State = ContextMap{}
+	}
+	cp.State[InputKey(node)] = &ExecutionResult{NodeName: node, Data: payload}
+	return c.resume(ctx, cp, map[string]any{node: payload})
+}
@@ -0,0 +1,53 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ninenhan/go-workflow/flow"
+	"github.com/ninenhan/go-workflow/store"
+)
+
+// approval 首次执行挂起，恢复后返回人工输入
+func approval(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+	if input, ok := ResumeInput(ctx); ok {
+		return SimpleResult(input), nil
+	}
+	return Interrupt("approve?"), nil
+}
+
+func TestInterruptAndResumeWithInput(t *testing.T) {
+	c := newCounter()
+	g := NewDSLGraph()
+	g.Checkpoints = store.NewInMemoryCheckpointStore()
+	g.StartWith("a", c.wrap("a", value("A"))).
+		Then("approve", c.wrap("approve", approval)).
+		Then("c", func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+			return SimpleResult(state[InputKey("approve")].Data), nil
+		})
+	res, err := g.RunWithDSL(context.Background(), nil)
+	if !errors.Is(err, ErrInterrupted) {
+		t.Fatalf("want ErrInterrupted, got %v", err)
+	}
+	if res.Status != flow.TaskInterrupted || len(res.Interrupts) != 1 {
+		t.Fatalf("unexpected result %s %+v", res.Status, res.Interrupts)
+	}
+	it := res.Interrupts[0]
+	if it.Node != "approve" || it.Prompt != "approve?" {
+		t.Fatalf("unexpected interrupt %+v", it)
+	}
+	if _, err := g.ResumeWithInput(context.Background(), res.RunID+"#c", "yes"); err == nil {
+		t.Fatal("resumed a node that is not waiting for input")
+	}
+	res, err = g.ResumeWithInput(context.Background(), it.Token, "yes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != flow.TaskCompleted || res.State["c"].Data != "yes" || res.State["approve"].Data != "yes" {
+		t.Fatalf("input not delivered: %s %v", res.Status, res.State["c"])
+	}
+	if c.get("a") != 1 || c.get("approve") != 2 {
+		t.Fatalf("unexpected calls %v", c.calls)
+	}
+}
@@ -0,0 +1,189 @@
+package workflow
+
+import "sync"
+
+// JoinMode 多入边（汇聚）节点等待上游的方式
+type JoinMode string
+
+const (
+	JoinAll  JoinMode = "all"    // 等待所有上游到达（默认），被分支跳过的上游视为已到达
+	JoinAny  JoinMode = "any"    // 任一上游完成即执行
+	JoinNofM JoinMode = "n_of_m" // 完成 Node.JoinN 个上游后执行
+)
+
+// JoinKey 汇聚节点合并后的上游结果在 ContextMap 中的 key
+func JoinKey(name string) string {
+	return name + "#join"
+}
+
+type joinDecision int
+
+const (
+	joinWait joinDecision = iota
+	joinRun
+	joinSkip // 上游被跳过，汇聚节点也跳过
+	joinFail // 上游失败，汇聚节点不再执行
+)
+
+// arrival 上游到达的方式
+type arrival int
+
+const (
+	arrivalDone arrival = iota
+	arrivalSkipped
+	arrivalFailed
+)
+
+type joinRound struct {
+	arrived   map[string]arrival
+	completed int
+	failed    bool
+	fired     bool
+}
+
+// joinTracker 记录一次运行中各汇聚节点的上游到达情况
+type joinTracker struct {
+	mu        sync.Mutex
+	nodes     map[string]*Node
+	edges     map[string][]string
+	preds     map[string][]string // 只记录从起点可达、且不是回边的入边
+	backEdges map[[2]string]bool
+	rounds    map[string]*joinRound
+}
+
+func newJoinTracker(nodes map[string]*Node, edges map[string][]string, start string) *joinTracker {
+	t := &joinTracker{
+		nodes:     nodes,
+		edges:     edges,
+		preds:     map[string][]string{},
+		backEdges: map[[2]string]bool{},
+		rounds:    map[string]*joinRound{},
+	}
+	// DFS 找出回边（循环），回边不参与汇聚计数
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	marks := map[string]int{}
+	var dfs func(string)
+	dfs = func(u string) {
+		marks[u] = visiting
+		for _, v := range edges[u] {
+			switch marks[v] {
+			case visiting:
+				t.backEdges[[2]string{u, v}] = true
+			case 0:
+				t.preds[v] = append(t.preds[v], u)
+				dfs(v)
+			default:
+				t.preds[v] = append(t.preds[v], u)
+			}
+		}
+		marks[u] = visited
+	}
+	if start != "" {
+		dfs(start)
+	}
+	return t
+}
+
+// isJoin 是否为汇聚节点：有多条入边，或显式声明了 Join
+func (t *joinTracker) isJoin(name string) bool {
+	node := t.nodes[name]
+	if node == nil {
+		return false
+	}
+	return len(t.preds[name]) > 1 || (node.Join != "" && len(t.preds[name]) > 0)
+}
+
+// forward 返回 name 的出边（不含回边），用于跳过传播
+func (t *joinTracker) forward(name string) []string {
+	var out []string
+	for _, n := range t.edges[name] {
+		if !t.backEdges[[2]string{name, n}] {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// arrive 上游 from 以 kind 方式到达汇聚节点 to，返回 to 的处理方式
+func (t *joinTracker) arrive(from, to string, kind arrival) joinDecision {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	preds := t.preds[to]
+	declared := false
+	for _, p := range preds {
+		if p == from {
+			declared = true
+			break
+		}
+	}
+	if !declared {
+		// 分支直接跳转过来，不参与计数
+		if kind == arrivalDone {
+			return joinRun
+		}
+		return joinWait
+	}
+
+	round := t.rounds[to]
+	if round == nil {
+		round = &joinRound{arrived: map[string]arrival{}}
+		t.rounds[to] = round
+	}
+	if _, ok := round.arrived[from]; !ok {
+		round.arrived[from] = kind
+		switch kind {
+		case arrivalDone:
+			round.completed++
+		case arrivalFailed:
+			round.failed = true
+		}
+	}
+
+	node := t.nodes[to]
+	need := 1
+	if node.Join == JoinNofM {
+		need = max(1, min(node.JoinN, len(preds)))
+	}
+	waitAll := node.Join == "" || node.Join == JoinAll
+	all := len(round.arrived) == len(preds)
+
+	decision := joinWait
+	switch {
+	case round.fired:
+	case round.failed && waitAll:
+		round.fired = true
+		decision = joinFail
+	case round.completed >= need && (!waitAll || all):
+		round.fired = true
+		decision = joinRun
+	case round.completed+len(preds)-len(round.arrived) < need:
+		// 剩余上游全部完成也不够，整个节点跳过
+		round.fired = true
+		decision = joinSkip
+		if round.failed {
+			decision = joinFail
+		}
+	}
+	if all {
+		// 一轮结束，重置以支持循环再次进入
+		delete(t.rounds, to)
+	}
+	return decision
+}
+
+// upstream 合并汇聚节点所有已完成上游的结果
+func (t *joinTracker) upstream(name string, state *State) *ExecutionResult {
+	data := map[string]any{}
+	raw := map[string]*ExecutionResult{}
+	for _, p := range t.preds[name] {
+		if r, ok := state.Get(p); ok && r != nil {
+			data[p] = r.Data
+			raw[p] = r
+		}
+	}
+	return &ExecutionResult{NodeName: name, Data: data, Raw: raw}
+}
@@ -0,0 +1,69 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// delayed 延迟 d 后返回 data
+func delayed(d time.Duration, data any) NodeFunc {
+	return func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+		time.Sleep(d)
+		return SimpleResult(data), nil
+	}
+}
+
+func TestJoinModes(t *testing.T) {
+	cases := []struct {
+		mode     JoinMode
+		n        int
+		upstream int
+	}{
+		{JoinAll, 0, 3},
+		{JoinAny, 0, 1},
+		{JoinNofM, 2, 2},
+	}
+	for _, tc := range cases {
+		t.Run(string(tc.mode), func(t *testing.T) {
+			c := newCounter()
+			g := NewDSLGraph()
+			g.StartWith("a", value(0))
+			g.Parallel("p", delayed(0, 1), delayed(20*time.Millisecond, 2), delayed(40*time.Millisecond, 3)).
+				JoinWith("j", c.wrap("j", func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+					return SimpleResult(len(state[JoinKey("j")].Data.(map[string]any))), nil
+				}), tc.mode, tc.n)
+			res, err := g.RunWithDSL(context.Background(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.get("j"); got != 1 {
+				t.Fatalf("join ran %d times, want 1", got)
+			}
+			if got := res.State["j"].Data; got != tc.upstream {
+				t.Fatalf("join saw %v upstream results, want %d", got, tc.upstream)
+			}
+		})
+	}
+}
+
+func TestJoinAllCountsSkippedBranches(t *testing.T) {
+	c := newCounter()
+	g := NewDSLGraph()
+	g.StartWith("a", value(0))
+	g.Branch("route", value(0), func(*ExecutionResult, ContextMap) string { return "left" }).
+		Case("left", "left", value("l")).
+		Case("right", "right", value("r")).
+		End()
+	g.From("left", "right").Then("j", c.wrap("j", value("joined")))
+	res, err := g.RunWithDSL(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.get("j") != 1 {
+		t.Fatalf("join ran %d times, want 1", c.get("j"))
+	}
+	if _, ok := res.State["right"]; ok {
+		t.Fatal("unselected branch ran")
+	}
+}
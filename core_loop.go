@@ -0,0 +1,51 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrMaxIterations       = errors.New("loop exceeded max iterations")
+	ErrContinueOutsideLoop = errors.New("continue returned outside a loop")
+)
+
+// DefaultMaxIterations 未设置 MaxIterations 时 LoopCond 循环的轮数上限
+const DefaultMaxIterations = 10000
+
+type LoopControl string
+
+const (
+	LoopBreak    LoopControl = "break"    // 记录本轮结果后结束循环
+	LoopContinue LoopControl = "continue" // 不记录本轮结果，继续判断 LoopCond
+)
+
+// LoopKey 循环状态在 ContextMap 中的 key，Data 为 {"iteration": 当前轮次（从 1 开始）}
+func LoopKey(name string) string {
+	return name + "#loop"
+}
+
+type loopIterationKey struct{}
+
+// LoopIteration 循环节点内取得当前轮次（从 1 开始），不在循环中时返回 0
+func LoopIteration(ctx context.Context) int {
+	n, _ := ctx.Value(loopIterationKey{}).(int)
+	return n
+}
+
+// Break 返回结果并结束循环
+func Break(data any) *ExecutionResult {
+	return &ExecutionResult{Data: data, Control: LoopBreak}
+}
+
+// Continue 跳过本轮结果，只能在设置了 LoopCond 的节点中使用；第一轮就跳过时节点结果为空
+func Continue() *ExecutionResult {
+	return &ExecutionResult{Control: LoopContinue}
+}
+
+func (n *Node) maxIterations() int {
+	if n.MaxIterations == 0 {
+		return DefaultMaxIterations
+	}
+	return n.MaxIterations
+}
@@ -0,0 +1,78 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// loopGraph 只有循环节点 l 的图
+func loopGraph(f NodeFunc, cond LoopCondFunc) *Graph {
+	g := NewDSLGraph()
+	g.AddNode("l", &Node{Execute: f, LoopCond: cond})
+	return g
+}
+
+func TestLoopAccumulatesAndBreaks(t *testing.T) {
+	g := loopGraph(func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+		switch i := LoopIteration(ctx); i {
+		case 2:
+			return Continue(), nil
+		case 4:
+			return Break(i), nil
+		default:
+			return SimpleResult(i), nil
+		}
+	}, func(ContextMap) bool { return true })
+	g.Nodes["l"].Accumulate = true
+	res, err := g.RunWithDSL(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := res.State["l"].Data.([]any)
+	if len(got) != 3 || got[0] != 1 || got[1] != 3 || got[2] != 4 {
+		t.Fatalf("unexpected accumulated data %v", got)
+	}
+}
+
+func TestLoopMaxIterations(t *testing.T) {
+	g := loopGraph(value(1), func(ContextMap) bool { return true })
+	g.Nodes["l"].MaxIterations = 3
+	if _, err := g.RunWithDSL(context.Background(), nil); !errors.Is(err, ErrMaxIterations) {
+		t.Fatalf("want ErrMaxIterations, got %v", err)
+	}
+}
+
+func TestContinueOnFirstIterationWritesEmptyResult(t *testing.T) {
+	var branched *ExecutionResult
+	g := loopGraph(func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+		return Continue(), nil
+	}, func(state ContextMap) bool { return state[LoopKey("l")].Data.(map[string]any)["iteration"].(int) < 2 })
+	g.Nodes["l"].Branch = func(r *ExecutionResult, state ContextMap) string {
+		branched = r
+		return "next"
+	}
+	g.AddNode("next", &Node{Execute: func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+		if state["l"] == nil {
+			return nil, errors.New("upstream result is nil")
+		}
+		return SimpleResult("ok"), nil
+	}})
+	g.AddEdge("l", "next")
+	if _, err := g.RunWithDSL(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if branched == nil {
+		t.Fatal("branch saw a nil result")
+	}
+}
+
+func TestContinueOutsideLoopFails(t *testing.T) {
+	g := NewDSLGraph()
+	g.StartWith("a", func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+		return Continue(), nil
+	})
+	if _, err := g.RunWithDSL(context.Background(), nil); !errors.Is(err, ErrContinueOutsideLoop) {
+		t.Fatalf("want ErrContinueOutsideLoop, got %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package workflow
+
+import (
+	"fmt"
+	"github.com/ninenhan/go-workflow/flow"
+	"strings"
+)
+
+// BranchOutcome 并行分支的执行结果
+type BranchOutcome struct {
+	Node   string         `json:"node"`
+	Status flow.JobStatus `json:"status"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// ParallelError 并行分支失败时返回，聚合所有分支的错误
+type ParallelError struct {
+	Node     string          // 发起并行的节点
+	Branches []BranchOutcome // 每个分支的结果（与边的顺序一致）
+	errs     []error
+}
+
+func (e *ParallelError) Error() string {
+	msgs := make([]string, 0, len(e.errs))
+	for _, err := range e.errs {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("parallel %s failed: %s", e.Node, strings.Join(msgs, "; "))
+}
+
+func (e *ParallelError) Unwrap() []error {
+	return e.errs
+}
+
+// Failed 返回真正失败的分支（不含被 fail-fast 取消的分支）
+func (e *ParallelError) Failed() []BranchOutcome {
+	var out []BranchOutcome
+	for _, b := range e.Branches {
+		if b.Status == flow.TaskFailed {
+			out = append(out, b)
+		}
+	}
+	return out
+}
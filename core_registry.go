@@ -1,9 +1,18 @@
 package workflow
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/ninenhan/go-workflow/flow"
+	"github.com/ninenhan/go-workflow/fn"
 	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 )
 
@@ -91,18 +100,425 @@ func (r *UnitRepository) ParsePhaseUnits(jsonData []byte, typeField string) ([]U
 //}
 
 // endregion
+
 var (
-	mu sync.RWMutex
+	ErrUnitExists     = errors.New("unit already registered")
+	ErrInvalidUnitRef = errors.New("invalid unit reference")
 )
-var UnitRegistry = map[string]ExecutableUnit{}
 
-func RegisterUnit(name string, unit ExecutableUnit) {
-	UnitRegistry[name] = unit
+// UnitFactory 创建新的单元实例，JSON 构建的每个节点各自持有一个实例
+type UnitFactory func() ExecutableUnit
+
+// UnitValidator 单元可选实现，参数解码后校验配置
+type UnitValidator interface {
+	Validate() error
+}
+
+var mu sync.RWMutex
+
+// UnitRegistry DefaultRegistry 中单元的工厂，键为规范名称。
+// 直接写入的条目只在 DefaultRegistry 没有同名单元时使用
+//
+// Deprecated: 使用 DefaultRegistry 的 Register、Find 等方法
+var UnitRegistry = map[string]UnitFactory{}
+
+// Registry 单元注册表，同时维护 Graph 使用的 ExecutableUnit 与 Pipeline 使用的 PhaseUnit。
+// 单元以 "[命名空间/]名称[@版本]" 引用，如 acme/http、HttpUnit@2；同名的多个版本可以共存。
+// 包级函数使用 DefaultRegistry，测试可以通过 NewRegistry 创建相互隔离的实例
+type Registry struct {
+	mu     sync.RWMutex
+	units  map[string][]registryEntry // 名称 -> 按版本升序
+	phases *flow.UnitRepository
+}
+
+type registryEntry struct {
+	version unitVersion // 未声明版本时为 0.0.0
+	newUnit func() (ExecutableUnit, error)
+	phase   bool // 原本是 PhaseUnit
 }
 
-func FindUnit(name string) (ExecutableUnit, bool) {
+var DefaultRegistry = &Registry{units: map[string][]registryEntry{}, phases: flow.DefaultUnitRepository()}
+
+// NewRegistry 创建独立的注册表，包括独立的 flow.UnitRepository
+func NewRegistry() *Registry {
+	return &Registry{units: map[string][]registryEntry{}, phases: &flow.UnitRepository{}}
+}
+
+// Phases 与注册表同步的 PhaseUnit 仓库，用于解析 Pipeline
+func (r *Registry) Phases() *flow.UnitRepository {
+	return r.phases
+}
+
+type registerOptions struct {
+	override bool
+}
+
+type RegisterOption func(*registerOptions)
+
+// Override 允许覆盖同名同版本的已注册单元，默认返回 ErrUnitExists
+func Override() RegisterOption {
+	return func(o *registerOptions) {
+		o.override = true
+	}
+}
+
+// Register 注册单元工厂，同时以 ExecutableUnitAdapter 注册到 Phases，可在 Pipeline 中使用
+func (r *Registry) Register(ref string, factory UnitFactory, opts ...RegisterOption) error {
+	parsed, err := ParseUnitRef(ref)
+	if err != nil {
+		return err
+	}
+	key := parsed.String()
+	newUnit := func() (ExecutableUnit, error) { return factory(), nil }
+	return r.add(parsed, registryEntry{newUnit: newUnit}, opts, func() {
+		r.phases.RegisterFactory(key, func() flow.PhaseUnit {
+			a := AdaptExecutableUnit(key, factory())
+			a.registry = r
+			return a
+		})
+	})
+}
+
+// RegisterUnit 以 unit 为原型注册，新实例是原型的深拷贝，原型的字段值作为默认值
+func (r *Registry) RegisterUnit(ref string, unit ExecutableUnit, opts ...RegisterOption) error {
+	return r.Register(ref, func() ExecutableUnit { return cloneValue(unit) }, opts...)
+}
+
+// RegisterPhaseUnit 注册 PhaseUnit 到 Phases，同时以 PhaseUnitAdapter 注册，可作为 Graph 节点使用
+func (r *Registry) RegisterPhaseUnit(ref string, unit flow.PhaseUnit, opts ...RegisterOption) error {
+	parsed, err := ParseUnitRef(ref)
+	if err != nil {
+		return err
+	}
+	key := parsed.String()
+	newUnit := func() (ExecutableUnit, error) {
+		phase, ok := r.phases.NewUnit(key)
+		if !ok {
+			return nil, fmt.Errorf("unit %s: phase unit cannot be created", key)
+		}
+		a := AdaptPhaseUnit(key, phase)
+		a.registry = r
+		return a, nil
+	}
+	return r.add(parsed, registryEntry{newUnit: newUnit, phase: true}, opts, func() {
+		r.phases.RegisterUnit(key, unit)
+	})
+}
+
+// add 加入版本索引。publish 在同一把锁内先于索引更新执行，查找到的单元总是已注册到 Phases。
+// 未声明版本的名称与 @0、@0.0.0 指向同一版本，两者不能互相覆盖
+func (r *Registry) add(parsed UnitRef, entry registryEntry, opts []RegisterOption, publish func()) error {
+	var o registerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	v, _ := parseUnitVersion(parsed.Version)
+	entry.version = v
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := r.units[parsed.Name]
+	i := sort.Search(len(entries), func(i int) bool { return !entries[i].version.less(v) })
+	exists := i < len(entries) && entries[i].version.parts == v.parts
+	if exists && (entries[i].version.n == 0) != (v.n == 0) {
+		other := parsed.Name
+		if entries[i].version.n > 0 {
+			other += "@" + entries[i].version.String()
+		}
+		return fmt.Errorf("%w: %s conflicts with %s", ErrUnitExists, parsed, other)
+	}
+	if exists && !o.override {
+		return fmt.Errorf("%w: %s", ErrUnitExists, parsed)
+	}
+	publish()
+	if exists {
+		entries[i] = entry
+	} else {
+		entries = slices.Insert(entries, i, entry)
+	}
+	r.units[parsed.Name] = entries
+	if r == DefaultRegistry {
+		mu.Lock()
+		UnitRegistry[parsed.String()] = func() ExecutableUnit {
+			unit, _ := entry.newUnit()
+			return unit
+		}
+		mu.Unlock()
+	}
+	return nil
+}
+
+// resolve 未指定版本时取最高版本，指定部分版本（如 @2、@2.1）时取匹配的最高版本
+func (r *Registry) resolve(ref string) (UnitRef, registryEntry, bool) {
+	parsed, err := ParseUnitRef(ref)
+	if err != nil {
+		return legacyUnit(r, ref)
+	}
+	want, _ := parseUnitVersion(parsed.Version)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entries := r.units[parsed.Name]
+	for i := len(entries) - 1; i >= 0; i-- {
+		if want.matches(entries[i].version) {
+			return UnitRef{Name: parsed.Name, Version: entries[i].version.String()}, entries[i], true
+		}
+	}
+	return legacyUnit(r, ref)
+}
+
+// legacyUnit 查找直接写入 UnitRegistry 的单元
+func legacyUnit(r *Registry, ref string) (UnitRef, registryEntry, bool) {
+	if r != DefaultRegistry {
+		return UnitRef{}, registryEntry{}, false
+	}
 	mu.RLock()
-	defer mu.RUnlock()
-	u, e := UnitRegistry[name]
-	return u, e
+	factory, ok := UnitRegistry[ref]
+	mu.RUnlock()
+	if !ok {
+		return UnitRef{}, registryEntry{}, false
+	}
+	return UnitRef{Name: ref}, registryEntry{newUnit: func() (ExecutableUnit, error) { return factory(), nil }}, true
+}
+
+// Find 返回默认配置的新实例
+func (r *Registry) Find(ref string) (ExecutableUnit, bool) {
+	unit, err := r.instance(ref)
+	return unit, err == nil
+}
+
+func (r *Registry) instance(ref string) (ExecutableUnit, error) {
+	_, entry, ok := r.resolve(ref)
+	if !ok {
+		return nil, fmt.Errorf("unit %s not found", ref)
+	}
+	return entry.newUnit()
+}
+
+// NewUnit 创建单元实例并将 params 按 JSON 解码到实例字段，params 中没有的字段取 default 标签或原型的值。
+// 未知的参数与仍为零值的必填字段（required:"true"）视为错误
+func (r *Registry) NewUnit(ref string, params map[string]any) (ExecutableUnit, error) {
+	unit, err := r.instance(ref)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn.ApplyDefaults(adaptedUnit(unit)); err != nil {
+		return nil, fmt.Errorf("unit %s: %w", ref, err)
+	}
+	if len(params) > 0 {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("unit %s: invalid params: %w", ref, err)
+		}
+		if err := decodeParams(data, unit); err != nil {
+			return nil, fmt.Errorf("unit %s: invalid params: %w", ref, err)
+		}
+	}
+	if err := checkRequired(unit); err != nil {
+		return nil, fmt.Errorf("unit %s: invalid params: %w", ref, err)
+	}
+	if v, ok := unit.(UnitValidator); ok {
+		if err := v.Validate(); err != nil {
+			return nil, fmt.Errorf("unit %s: %w", ref, err)
+		}
+	}
+	return unit, nil
+}
+
+// decodeParams 按 JSON 解码参数，拒绝 v 中不存在的字段
+func decodeParams(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// adaptedUnit 适配器中被适配的单元，参数解码到该单元
+func adaptedUnit(unit any) any {
+	switch a := unit.(type) {
+	case *PhaseUnitAdapter:
+		return a.Phase
+	case *ExecutableUnitAdapter:
+		return a.Unit
+	}
+	return unit
+}
+
+// checkRequired 适配器检查被适配的单元
+func checkRequired(unit any) error {
+	if missing := fn.MissingRequired(adaptedUnit(unit)); len(missing) > 0 {
+		return fmt.Errorf("missing required params: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// RegisterUnit 以覆盖方式注册到 DefaultRegistry；name 不是有效的单元引用时只写入 UnitRegistry
+//
+// Deprecated: 使用 DefaultRegistry.RegisterUnit，重复注册与无效引用会返回错误
+func RegisterUnit(name string, unit ExecutableUnit) {
+	if err := DefaultRegistry.RegisterUnit(name, unit, Override()); err != nil {
+		mu.Lock()
+		defer mu.Unlock()
+		UnitRegistry[name] = func() ExecutableUnit { return cloneValue(unit) }
+	}
+}
+
+func RegisterUnitFactory(ref string, factory UnitFactory, opts ...RegisterOption) error {
+	return DefaultRegistry.Register(ref, factory, opts...)
+}
+
+func RegisterPhaseUnit(ref string, unit flow.PhaseUnit, opts ...RegisterOption) error {
+	return DefaultRegistry.RegisterPhaseUnit(ref, unit, opts...)
+}
+
+func FindUnit(ref string) (ExecutableUnit, bool) {
+	return DefaultRegistry.Find(ref)
+}
+
+func NewUnit(ref string, params map[string]any) (ExecutableUnit, error) {
+	return DefaultRegistry.NewUnit(ref, params)
+}
+
+// UnitRef 单元引用，Name 含命名空间
+type UnitRef struct {
+	Name    string
+	Version string
+}
+
+var unitNameRegex = regexp.MustCompile(`^[A-Za-z0-9_.\-]+(/[A-Za-z0-9_.\-]+)*$`)
+
+// ParseUnitRef 解析 "[命名空间/]名称[@版本]"，版本为 1 到 3 段数字，可带 v 前缀
+func ParseUnitRef(ref string) (UnitRef, error) {
+	name, version, _ := strings.Cut(ref, "@")
+	if !unitNameRegex.MatchString(name) {
+		return UnitRef{}, fmt.Errorf("%w: %q", ErrInvalidUnitRef, ref)
+	}
+	if version != "" {
+		if _, err := parseUnitVersion(version); err != nil {
+			return UnitRef{}, fmt.Errorf("%w: %q", ErrInvalidUnitRef, ref)
+		}
+	}
+	return UnitRef{Name: name, Version: strings.TrimPrefix(version, "v")}, nil
+}
+
+// Namespace 名称中最后一个 "/" 之前的部分
+func (r UnitRef) Namespace() string {
+	if i := strings.LastIndex(r.Name, "/"); i >= 0 {
+		return r.Name[:i]
+	}
+	return ""
+}
+
+// String 注册时的规范名称，版本补全为三段
+func (r UnitRef) String() string {
+	if r.Version == "" {
+		return r.Name
+	}
+	v, _ := parseUnitVersion(r.Version)
+	return r.Name + "@" + v.String()
+}
+
+// unitVersion n 为声明的段数，查询时只比较前 n 段
+type unitVersion struct {
+	parts [3]int
+	n     int
+}
+
+func parseUnitVersion(s string) (unitVersion, error) {
+	var v unitVersion
+	if s == "" {
+		return v, nil
+	}
+	segments := strings.Split(strings.TrimPrefix(s, "v"), ".")
+	if len(segments) > 3 {
+		return v, fmt.Errorf("invalid version %q", s)
+	}
+	for i, seg := range segments {
+		n, err := strconv.Atoi(seg)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version %q", s)
+		}
+		v.parts[i] = n
+	}
+	v.n = len(segments)
+	return v, nil
+}
+
+func (v unitVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.parts[0], v.parts[1], v.parts[2])
+}
+
+func (v unitVersion) less(o unitVersion) bool {
+	return slices.Compare(v.parts[:], o.parts[:]) < 0
+}
+
+// matches 已注册的 full 是否满足查询 v
+func (v unitVersion) matches(full unitVersion) bool {
+	return slices.Equal(v.parts[:v.n], full.parts[:v.n])
+}
+
+// cloneValue 深拷贝结构体指针，新实例与原型不共享导出字段中的 map、slice 与指针；
+// 未导出字段、func 与 chan 仍与原型共享。其他类型无法复制，返回原值
+func cloneValue[T any](proto T) T {
+	v := reflect.ValueOf(proto)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return proto
+	}
+	return deepCopy(v, map[uintptr]reflect.Value{}).Interface().(T)
+}
+
+// deepCopy seen 记录已复制的指针，保留原值中的共享与环
+func deepCopy(v reflect.Value, seen map[uintptr]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		if cp, ok := seen[v.Pointer()]; ok {
+			return cp
+		}
+		cp := reflect.New(v.Elem().Type())
+		seen[v.Pointer()] = cp
+		cp.Elem().Set(deepCopy(v.Elem(), seen))
+		return cp
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.New(v.Type()).Elem()
+		cp.Set(deepCopy(v.Elem(), seen))
+		return cp
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			cp.SetMapIndex(iter.Key(), deepCopy(iter.Value(), seen))
+		}
+		return cp
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			cp.Index(i).Set(deepCopy(v.Index(i), seen))
+		}
+		return cp
+	case reflect.Array:
+		cp := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			cp.Index(i).Set(deepCopy(v.Index(i), seen))
+		}
+		return cp
+	case reflect.Struct:
+		cp := reflect.New(v.Type()).Elem()
+		cp.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if f := cp.Field(i); f.CanSet() {
+				f.Set(deepCopy(v.Field(i), seen))
+			}
+		}
+		return cp
+	}
+	return v
 }
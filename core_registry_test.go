@@ -0,0 +1,265 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNewUnitDecodesParams(t *testing.T) {
+	r := newTestRegistry(t)
+	unit, err := r.NewUnit("test/greet", map[string]any{"greeting": "hi", "suffix": "?"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := unit.(*greetUnit); g.Greeting != "hi" || g.Suffix != "?" {
+		t.Fatalf("params not decoded: %+v", g)
+	}
+	other, _ := r.NewUnit("test/greet", map[string]any{"greeting": "yo"})
+	if other == unit || other.(*greetUnit).Suffix != "" {
+		t.Fatal("units share state")
+	}
+}
+
+func TestNewUnitRejectsInvalidParams(t *testing.T) {
+	r := newTestRegistry(t)
+	cases := map[string]map[string]any{
+		"unknown field":  {"greeting": "hi", "sufix": "!"},
+		"wrong type":     {"greeting": 1},
+		"missing field":  {"suffix": "!"},
+		"missing params": nil,
+	}
+	for name, params := range cases {
+		if _, err := r.NewUnit("test/greet", params); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestValidateReportsInvalidParams(t *testing.T) {
+	r := newTestRegistry(t)
+	_, err := r.BuildGraphFromJSON([]byte(`{"nodes": {"a": {"unit_id": "test/greet", "params": {"greting": "hi"}}}}`))
+	var ce *CompileError
+	if !errors.As(err, &ce) || !hasDiag(ce.Diagnostics, DiagInvalidParams) {
+		t.Fatalf("want invalid_params, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `"greting"`) {
+		t.Fatalf("error does not name the unknown field: %v", err)
+	}
+}
+
+// labelUnit 带 map、slice 与指针参数的单元
+type labelUnit struct {
+	Unit
+	Labels map[string]string `json:"labels,omitempty"`
+	Tags   []string          `json:"tags,omitempty"`
+	Limit  *int              `json:"limit,omitempty"`
+}
+
+func (u *labelUnit) GetUnitMeta() *Unit {
+	return &u.Unit
+}
+
+func (u *labelUnit) Execute(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+	return SimpleResult(len(u.Labels)), nil
+}
+
+func TestNodesDoNotShareThePrototype(t *testing.T) {
+	r := NewRegistry()
+	limit := 1
+	proto := &labelUnit{Labels: map[string]string{"env": "prod"}, Tags: []string{"a"}, Limit: &limit}
+	if err := r.RegisterUnit("test/label", proto); err != nil {
+		t.Fatal(err)
+	}
+	g, err := r.BuildGraphFromJSON([]byte(`{"nodes": {
+		"one": {"unit_id": "test/label", "params": {"labels": {"team": "core"}, "tags": ["b"], "limit": 5}},
+		"two": {"unit_id": "test/label"}
+	}, "edges": {"one": ["two"]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := g.RunWithDSL(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.State["one"].Data != 2 || res.State["two"].Data != 1 {
+		t.Fatalf("nodes share labels: one=%v two=%v", res.State["one"].Data, res.State["two"].Data)
+	}
+	unit, _ := r.Find("test/label")
+	u := unit.(*labelUnit)
+	u.Tags[0] = "z"
+	*u.Limit = 9
+	if len(proto.Labels) != 1 || proto.Tags[0] != "a" || *proto.Limit != 1 {
+		t.Fatalf("prototype changed: %+v limit=%d", proto, *proto.Limit)
+	}
+}
+
+// defaultsUnit 参数带 default 标签的单元
+type defaultsUnit struct {
+	Unit
+	Method  string `json:"method" default:"GET"`
+	Retries int    `json:"retries" default:"3"`
+	Verbose bool   `json:"verbose" default:"true"`
+}
+
+func (u *defaultsUnit) GetUnitMeta() *Unit {
+	return &u.Unit
+}
+
+func (u *defaultsUnit) Execute(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+	return SimpleResult(u.Method), nil
+}
+
+func TestNewUnitAppliesTagDefaults(t *testing.T) {
+	r := NewRegistry()
+	if err := r.RegisterUnit("test/defaults", &defaultsUnit{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RegisterUnit("test/put", &defaultsUnit{Method: "PUT"}); err != nil {
+		t.Fatal(err)
+	}
+	unit, err := r.NewUnit("test/defaults", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u := unit.(*defaultsUnit); u.Method != "GET" || u.Retries != 3 || !u.Verbose {
+		t.Fatalf("defaults not applied: %+v", u)
+	}
+	unit, _ = r.NewUnit("test/defaults", map[string]any{"method": "POST", "retries": 0})
+	if u := unit.(*defaultsUnit); u.Method != "POST" || u.Retries != 0 || !u.Verbose {
+		t.Fatalf("params should override defaults: %+v", u)
+	}
+	unit, _ = r.NewUnit("test/put", nil)
+	if u := unit.(*defaultsUnit); u.Method != "PUT" {
+		t.Fatalf("prototype value replaced by default: %+v", u)
+	}
+}
+
+func TestRegistryVersions(t *testing.T) {
+	r := NewRegistry()
+	for _, v := range []string{"1.0.0", "1.2.0", "2.0.1"} {
+		if err := r.RegisterUnit("acme/greet@"+v, &greetUnit{Greeting: v}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cases := map[string]string{
+		"acme/greet":        "2.0.1",
+		"acme/greet@1":      "1.2.0",
+		"acme/greet@1.0":    "1.0.0",
+		"acme/greet@v2.0.1": "2.0.1",
+	}
+	for ref, want := range cases {
+		unit, ok := r.Find(ref)
+		if !ok || unit.(*greetUnit).Greeting != want {
+			t.Errorf("%s resolved to %v, want %s", ref, unit, want)
+		}
+	}
+	if _, ok := r.Find("acme/greet@3"); ok {
+		t.Error("missing major version resolved")
+	}
+	if _, ok := r.Find("greet"); ok {
+		t.Error("name resolved without its namespace")
+	}
+}
+
+func TestRegistryDuplicatesAndOverride(t *testing.T) {
+	r := NewRegistry()
+	if err := r.RegisterUnit("greet@1", &greetUnit{Greeting: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RegisterUnit("greet@1.0.0", &greetUnit{Greeting: "b"}); !errors.Is(err, ErrUnitExists) {
+		t.Fatalf("want ErrUnitExists, got %v", err)
+	}
+	if err := r.RegisterUnit("greet@1.0.0", &greetUnit{Greeting: "b"}, Override()); err != nil {
+		t.Fatal(err)
+	}
+	if unit, _ := r.Find("greet"); unit.(*greetUnit).Greeting != "b" {
+		t.Fatal("override did not replace the unit")
+	}
+	if err := r.RegisterUnit("bad name", &greetUnit{}); !errors.Is(err, ErrInvalidUnitRef) {
+		t.Fatalf("want ErrInvalidUnitRef, got %v", err)
+	}
+	if _, ok := DefaultRegistry.Find("greet"); ok {
+		t.Fatal("isolated registry leaked into DefaultRegistry")
+	}
+}
+
+func TestRegistryUnversionedConflict(t *testing.T) {
+	r := NewRegistry()
+	if err := r.RegisterUnit("greet", &greetUnit{Greeting: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, ref := range []string{"greet@0", "greet@0.0.0"} {
+		if err := r.RegisterUnit(ref, &greetUnit{Greeting: "b"}, Override()); !errors.Is(err, ErrUnitExists) {
+			t.Fatalf("%s: want ErrUnitExists, got %v", ref, err)
+		}
+	}
+	if _, ok := r.Phases().NewUnit("greet@0.0.0"); ok {
+		t.Fatal("rejected unit was registered to Phases")
+	}
+	if unit, _ := r.Find("greet"); unit.(*greetUnit).Greeting != "a" {
+		t.Fatal("unversioned unit was replaced")
+	}
+}
+
+func TestRegisterPhaseUnitConstructionError(t *testing.T) {
+	r := NewRegistry()
+	if err := r.RegisterPhaseUnit("test/upper", &upperPhase{}); err != nil {
+		t.Fatal(err)
+	}
+	// Phases 中的同名注册被替换为无法创建 PhaseUnit 的类型
+	r.Phases().RegisterUnit("test/upper", &struct{}{})
+	if _, err := r.NewUnit("test/upper", nil); err == nil {
+		t.Fatal("want construction error")
+	}
+	if _, ok := r.Find("test/upper"); ok {
+		t.Fatal("Find returned a unit that cannot be created")
+	}
+}
+
+func TestDeprecatedRegisterUnit(t *testing.T) {
+	RegisterUnit("test/legacy-greet", &greetUnit{Greeting: "old"})
+	RegisterUnit("test/legacy-greet", &greetUnit{Greeting: "new"})
+	if unit, ok := FindUnit("test/legacy-greet"); !ok || unit.(*greetUnit).Greeting != "new" {
+		t.Fatalf("re-registration did not override: %v", unit)
+	}
+	mu.RLock()
+	factory, ok := UnitRegistry["test/legacy-greet"]
+	mu.RUnlock()
+	if !ok || factory().(*greetUnit).Greeting != "new" {
+		t.Fatal("UnitRegistry does not mirror DefaultRegistry")
+	}
+	RegisterUnit("legacy greet", &greetUnit{Greeting: "spaced"})
+	if unit, ok := FindUnit("legacy greet"); !ok || unit.(*greetUnit).Greeting != "spaced" {
+		t.Fatal("name that is not a unit reference was not kept")
+	}
+}
+
+func TestRegistryConcurrentAccess(t *testing.T) {
+	r := NewRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if err := r.RegisterUnit(fmt.Sprintf("greet@1.%d", i), &greetUnit{Greeting: "hi"}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			r.Find("greet@1")
+			r.ListUnits()
+		}()
+	}
+	wg.Wait()
+	if unit, ok := r.Find("greet@1"); !ok || unit == nil {
+		t.Fatal("latest version not found")
+	}
+	if n := len(r.ListUnits()); n != 16 {
+		t.Fatalf("want 16 versions, got %d", n)
+	}
+}
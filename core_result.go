@@ -0,0 +1,99 @@
+package workflow
+
+import (
+	"errors"
+	"github.com/ninenhan/go-workflow/flow"
+	"time"
+)
+
+// RunResult 一次运行的结果，Run、RunWithDSL、Resume 均返回它（图校验失败时为 nil）
+type RunResult struct {
+	RunID      string             `json:"run_id"`
+	Status     flow.JobStatus     `json:"status"`
+	Trace      []TraceEntry       `json:"trace"`             // 按结束时间排列的每次尝试
+	Outputs    ContextMap         `json:"outputs,omitempty"` // 终止节点（没有下游）的结果
+	State      ContextMap         `json:"state"`             // 运行结束时的状态快照
+	Interrupts []PendingInterrupt `json:"interrupts,omitempty"`
+	StartedAt  time.Time          `json:"started_at"`
+	EndedAt    time.Time          `json:"ended_at"`
+	Duration   time.Duration      `json:"duration"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// TraceEntry 节点的一次尝试
+type TraceEntry struct {
+	Node      string         `json:"node"`
+	Attempt   int            `json:"attempt"`
+	Status    flow.JobStatus `json:"status"`
+	StartedAt time.Time      `json:"started_at"`
+	EndedAt   time.Time      `json:"ended_at"`
+	Duration  time.Duration  `json:"duration"`
+	Error     string         `json:"error,omitempty"`
+	// Children 本次尝试中运行的子图
+	Children []*RunResult `json:"children,omitempty"`
+}
+
+// runStatus 由运行错误得出最终状态
+func runStatus(err error) flow.JobStatus {
+	switch {
+	case err == nil:
+		return flow.TaskCompleted
+	case errors.Is(err, ErrInterrupted):
+		return flow.TaskInterrupted
+	case errors.Is(err, ErrRunCancelled):
+		return flow.TaskCancelled
+	}
+	return flow.TaskFailed
+}
+
+// trace 记录一次尝试，worker 并发调用
+func (s *scheduler) trace(name string, attempt int, started time.Time, children *childRuns, result *ExecutionResult, err error) {
+	ended := time.Now()
+	entry := TraceEntry{
+		Node:      name,
+		Attempt:   attempt,
+		Status:    flow.TaskCompleted,
+		StartedAt: started,
+		EndedAt:   ended,
+		Duration:  ended.Sub(started),
+		Children:  children.list(),
+	}
+	switch {
+	case err != nil:
+		err = s.classify(err)
+		entry.Status = runStatus(err)
+		entry.Error = err.Error()
+	case result != nil && result.Interrupt != nil:
+		entry.Status = flow.TaskInterrupted
+	}
+	s.traceMu.Lock()
+	defer s.traceMu.Unlock()
+	s.traces = append(s.traces, entry)
+}
+
+// result 汇总运行结果
+func (s *scheduler) result(err error) *RunResult {
+	state := s.state.Snapshot()
+	outputs := ContextMap{}
+	for _, name := range s.terminals {
+		if r, ok := state[name]; ok {
+			outputs[name] = r
+		}
+	}
+	ended := time.Now()
+	r := &RunResult{
+		RunID:      s.runID,
+		Status:     runStatus(err),
+		Trace:      s.traces,
+		Outputs:    outputs,
+		State:      state,
+		Interrupts: s.interrupts,
+		StartedAt:  s.started,
+		EndedAt:    ended,
+		Duration:   ended.Sub(s.started),
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
@@ -0,0 +1,45 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ninenhan/go-workflow/flow"
+)
+
+func TestRunResultReportsTraceAndOutputs(t *testing.T) {
+	g := NewDSLGraph()
+	g.StartWith("a", value(1))
+	g.Parallel("p", value(2), value(3)).End()
+	res, err := g.RunWithDSL(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RunID == "" || res.Status != flow.TaskCompleted || res.Error != "" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if len(res.Trace) != 3 {
+		t.Fatalf("trace has %d entries, want 3", len(res.Trace))
+	}
+	if len(res.Outputs) != 2 || res.Outputs["p_p0"] == nil || res.Outputs["p_p1"] == nil {
+		t.Fatalf("outputs should hold terminal nodes only: %v", res.Outputs)
+	}
+	if res.EndedAt.Before(res.StartedAt) {
+		t.Fatal("ended before started")
+	}
+}
+
+func TestRunStatus(t *testing.T) {
+	cases := map[error]flow.JobStatus{
+		nil:                           flow.TaskCompleted,
+		&InterruptedError{RunID: "r"}: flow.TaskInterrupted,
+		errors.Join(ErrRunCancelled, ErrNodeTimeout): flow.TaskCancelled,
+		errors.New("boom"):                           flow.TaskFailed,
+	}
+	for err, want := range cases {
+		if got := runStatus(err); got != want {
+			t.Errorf("runStatus(%v) = %s, want %s", err, got, want)
+		}
+	}
+}
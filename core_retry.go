@@ -0,0 +1,134 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+type BackoffKind string
+
+const (
+	BackoffFixed       BackoffKind = "fixed"
+	BackoffExponential BackoffKind = "exponential"
+)
+
+// RetryPolicy 节点失败后的重试策略
+type RetryPolicy struct {
+	MaxAttempts    int           // 总尝试次数（含首次），<=1 不重试
+	Backoff        BackoffKind   // 默认 fixed
+	Delay          time.Duration // 首次重试前的等待
+	MaxDelay       time.Duration // 指数退避的上限，0 不限制
+	Jitter         float64       // 0~1，在等待时间上随机浮动的比例
+	AttemptTimeout time.Duration // 单次尝试的超时，0 不限制
+	RetryOn        []string      // 错误信息包含任一关键字才重试，为空则全部重试
+	RetryIf        func(err error) bool
+}
+
+// retryPolicyJSON 时间以毫秒表示，便于 UI 编辑
+type retryPolicyJSON struct {
+	MaxAttempts      int         `json:"max_attempts,omitempty"`
+	Backoff          BackoffKind `json:"backoff,omitempty"`
+	DelayMs          int64       `json:"delay_ms,omitempty"`
+	MaxDelayMs       int64       `json:"max_delay_ms,omitempty"`
+	Jitter           float64     `json:"jitter,omitempty"`
+	AttemptTimeoutMs int64       `json:"attempt_timeout_ms,omitempty"`
+	RetryOn          []string    `json:"retry_on,omitempty"`
+}
+
+func (p RetryPolicy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(retryPolicyJSON{
+		MaxAttempts:      p.MaxAttempts,
+		Backoff:          p.Backoff,
+		DelayMs:          p.Delay.Milliseconds(),
+		MaxDelayMs:       p.MaxDelay.Milliseconds(),
+		Jitter:           p.Jitter,
+		AttemptTimeoutMs: p.AttemptTimeout.Milliseconds(),
+		RetryOn:          p.RetryOn,
+	})
+}
+
+func (p *RetryPolicy) UnmarshalJSON(data []byte) error {
+	var aux retryPolicyJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	p.MaxAttempts = aux.MaxAttempts
+	p.Backoff = aux.Backoff
+	p.Delay = time.Duration(aux.DelayMs) * time.Millisecond
+	p.MaxDelay = time.Duration(aux.MaxDelayMs) * time.Millisecond
+	p.Jitter = aux.Jitter
+	p.AttemptTimeout = time.Duration(aux.AttemptTimeoutMs) * time.Millisecond
+	p.RetryOn = aux.RetryOn
+	return nil
+}
+
+// retryable 判断 err 是否值得重试；整个运行被取消时不重试
+func (p *RetryPolicy) retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if p.RetryIf != nil {
+		return p.RetryIf(err)
+	}
+	if len(p.RetryOn) == 0 {
+		return true
+	}
+	msg := err.Error()
+	for _, key := range p.RetryOn {
+		if strings.Contains(msg, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// wait 第 attempt 次失败后的等待时间（attempt 从 1 开始）
+func (p *RetryPolicy) wait(attempt int) time.Duration {
+	d := p.Delay
+	if p.Backoff == BackoffExponential {
+		d = time.Duration(float64(p.Delay) * math.Pow(2, float64(attempt-1)))
+		if p.MaxDelay > 0 && d > p.MaxDelay {
+			d = p.MaxDelay
+		}
+	}
+	if p.Jitter > 0 && d > 0 {
+		j := min(p.Jitter, 1)
+		d = time.Duration(float64(d) * (1 - j + 2*j*rand.Float64()))
+	}
+	return d
+}
+
+// executeWithRetry 按策略执行 call，返回结果、实际尝试次数与最后一次的错误
+func executeWithRetry(ctx context.Context, p *RetryPolicy, call func(context.Context) (*ExecutionResult, error)) (*ExecutionResult, int, error) {
+	attempts := 1
+	if p != nil && p.MaxAttempts > 1 {
+		attempts = p.MaxAttempts
+	}
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if p != nil && p.AttemptTimeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, p.AttemptTimeout)
+		}
+		result, err := call(attemptCtx)
+		cancel()
+		if err == nil {
+			return result, attempt, nil
+		}
+		lastErr = err
+		if attempt == attempts || !p.retryable(ctx, err) {
+			return nil, attempt, lastErr
+		}
+		select {
+		case <-ctx.Done():
+			return nil, attempt, errors.Join(lastErr, ctx.Err())
+		case <-time.After(p.wait(attempt)):
+		}
+	}
+	return nil, attempts, lastErr
+}
@@ -0,0 +1,58 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flaky 前 fails 次调用失败
+func flaky(fails int32, msg string) NodeFunc {
+	var n atomic.Int32
+	return func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+		if n.Add(1) <= fails {
+			return nil, errors.New(msg)
+		}
+		return SimpleResult("ok"), nil
+	}
+}
+
+func TestRetryUntilSuccess(t *testing.T) {
+	g := NewDSLGraph()
+	g.StartWith("a", flaky(2, "temporary"))
+	g.Nodes["a"].Retry = &RetryPolicy{MaxAttempts: 3, Delay: time.Millisecond}
+	res, err := g.RunWithDSL(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.State["a"].Attempts; got != 3 {
+		t.Fatalf("attempts %d, want 3", got)
+	}
+	if len(res.Trace) != 3 {
+		t.Fatalf("trace has %d entries, want one per attempt", len(res.Trace))
+	}
+}
+
+func TestRetryOnFiltersErrors(t *testing.T) {
+	c := newCounter()
+	g := NewDSLGraph()
+	g.StartWith("a", c.wrap("a", flaky(2, "fatal")))
+	g.Nodes["a"].Retry = &RetryPolicy{MaxAttempts: 3, RetryOn: []string{"temporary"}}
+	if _, err := g.RunWithDSL(context.Background(), nil); err == nil {
+		t.Fatal("want error")
+	}
+	if c.get("a") != 1 {
+		t.Fatalf("non-matching error retried %d times", c.get("a")-1)
+	}
+}
+
+func TestRetryWaitBackoff(t *testing.T) {
+	p := &RetryPolicy{Backoff: BackoffExponential, Delay: 10 * time.Millisecond, MaxDelay: 30 * time.Millisecond}
+	for attempt, want := range map[int]time.Duration{1: 10 * time.Millisecond, 2: 20 * time.Millisecond, 3: 30 * time.Millisecond} {
+		if got := p.wait(attempt); got != want {
+			t.Errorf("wait(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
@@ -0,0 +1,534 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ninenhan/go-workflow/flow"
+	"github.com/ninenhan/go-workflow/fn"
+	"slices"
+	"sync"
+	"time"
+)
+
+// branchRef 任务所属的并行分支：parent 为发起 fan-out 的节点，child 为分支入口
+type branchRef struct {
+	parent string
+	child  string
+}
+
+type task struct {
+	from    string
+	name    string
+	lineage []branchRef  // 由外到内的并行分支
+	resume  *resumeInput // 挂起后恢复时注入的人工输入
+}
+
+type taskDone struct {
+	task
+	result      *ExecutionResult
+	next        []string
+	branched    bool // next 由 Branch 决定
+	interrupted bool
+	err         error
+}
+
+type taskFailure struct {
+	task
+	err       error
+	cancelled bool
+}
+
+// scheduler 按拓扑顺序调度一次运行：上游完成后计算就绪节点，交给有上限的 worker 并发执行。
+// 调度、汇聚计数、跳过传播都在 dispatch 循环中单线程完成，节点执行在 worker 中进行。
+type scheduler struct {
+	g           *Graph
+	ctx         context.Context
+	parent      context.Context // 调用方 context（含运行超时），取消即为 ErrRunCancelled
+	stop        context.CancelFunc
+	cancel      context.CancelFunc
+	state       *State
+	joins       *joinTracker
+	fanouts     map[string][]BranchOutcome
+	joinLineage map[string][]branchRef
+	failures    []taskFailure
+	runID       string
+	start       string
+	log         []CompletedNode            // 已完成节点，写入检查点
+	replay      map[string][]CompletedNode // Resume 时待重放的已完成节点
+	inflight    map[string]int
+	dropped     []string       // 因取消未执行的节点
+	inputs      map[string]any // 待注入挂起节点的人工输入
+	interrupts  []PendingInterrupt
+	terminals   []string // 完成且没有下游的节点
+	started     time.Time
+	traceMu     sync.Mutex
+	traces      []TraceEntry
+	events      *flow.Emitter
+}
+
+func newScheduler(ctx context.Context, g *Graph, start string, state *State) *scheduler {
+	s := &scheduler{
+		g:           g,
+		state:       state,
+		joins:       newJoinTracker(g.Nodes, g.Edges, start),
+		fanouts:     map[string][]BranchOutcome{},
+		joinLineage: map[string][]branchRef{},
+		runID:       newRunID(ctx),
+		start:       start,
+		inflight:    map[string]int{},
+		started:     time.Now(),
+	}
+	ctx = WithRunID(ctx, s.runID)
+	s.parent, s.stop = ctx, func() {}
+	if g.Timeout > 0 {
+		s.parent, s.stop = context.WithTimeout(ctx, g.Timeout)
+	}
+	s.events = flow.NewEmitter(g.Events, s.runID, g.Listeners)
+	s.ctx, s.cancel = context.WithCancel(context.WithValue(WithState(s.parent, state), emitterKey{}, s.events))
+	return s
+}
+
+func (s *scheduler) run(start string) (*RunResult, error) {
+	defer s.stop()
+	defer s.cancel()
+	s.emit(flow.Event{Type: flow.EventRunStarted, Node: start, Status: flow.TaskRunning})
+	queue := []task{{name: start}}
+	done := make(chan taskDone)
+	running := 0
+	for len(queue) > 0 || running > 0 {
+		// 有节点挂起后不再调度新节点，等待执行中的节点结束
+		for len(queue) > 0 && len(s.interrupts) == 0 && (s.g.MaxConcurrency <= 0 || running < s.g.MaxConcurrency) {
+			t := queue[0]
+			queue = queue[1:]
+			if err := s.ctx.Err(); err != nil {
+				// 已取消：不再调度新节点
+				s.mark(t.lineage, flow.TaskCancelled, err)
+				s.dropped = append(s.dropped, t.name)
+				continue
+			}
+			if done, ok := s.replayed(t.name); ok {
+				// 恢复运行：已完成的节点直接沿用记录的下游
+				queue = append(queue, s.complete(taskDone{task: t, next: done.Next, branched: done.Branched})...)
+				continue
+			}
+			if payload, ok := s.inputs[t.name]; ok {
+				t.resume = &resumeInput{payload: payload}
+				delete(s.inputs, t.name)
+			}
+			running++
+			s.inflight[t.name]++
+			s.emit(flow.Event{Type: flow.EventNodeScheduled, Node: t.name, Status: flow.TaskPending})
+			go func(t task) {
+				done <- s.execute(t)
+			}(t)
+		}
+		if running == 0 {
+			break
+		}
+		d := <-done
+		running--
+		s.inflight[d.name]--
+		queue = append(queue, s.complete(d)...)
+		s.checkpoint(s.pending(queue))
+	}
+	s.finish()
+	err := s.err()
+	s.finalize(err, s.pending(nil))
+	result := s.result(err)
+	s.state.close()
+	s.emit(flow.Event{Type: flow.EventRunFinished, Status: result.Status, Data: result, Error: result.Error})
+	s.events.Expire(s.g.EventRetention)
+	return result, err
+}
+
+// pending 未完成的节点：执行中、排队中、失败或被取消的节点
+func (s *scheduler) pending(queue []task) []string {
+	var out []string
+	for name, n := range s.inflight {
+		for range n {
+			out = append(out, name)
+		}
+	}
+	for _, t := range queue {
+		out = append(out, t.name)
+	}
+	for _, f := range s.failures {
+		out = append(out, f.name)
+	}
+	for _, it := range s.interrupts {
+		out = append(out, it.Node)
+	}
+	return append(out, s.dropped...)
+}
+
+// execute 在 worker 中执行单个节点（含 LoopCond 循环）
+func (s *scheduler) execute(t task) taskDone {
+	d := taskDone{task: t}
+	node, ok := s.g.Nodes[t.name]
+	if !ok {
+		d.err = fmt.Errorf("node %s not found", t.name)
+		return d
+	}
+	if s.g.Hooks.Before != nil {
+		s.g.Hooks.Before(t.name, s.state.Snapshot())
+	}
+
+	var result *ExecutionResult
+	var attempts int
+	var err error
+	nodeCtx, cancel := s.ctx, context.CancelFunc(func() {})
+	nodeCtx = context.WithValue(nodeCtx, nodeNameKey{}, t.name)
+	if t.resume != nil {
+		nodeCtx = context.WithValue(nodeCtx, resumeKey{}, t.resume)
+	}
+	if node.Timeout > 0 {
+		nodeCtx, cancel = context.WithTimeout(nodeCtx, node.Timeout)
+	}
+	defer cancel()
+	fail := func(err error) taskDone {
+		failed := &ExecutionResult{
+			Error:    err.Error(),
+			Attempts: attempts,
+		}
+		s.state.Set(t.name, failed)
+		if s.g.Hooks.After != nil {
+			s.g.Hooks.After(t.name, failed, err, s.state.Snapshot())
+		}
+		s.emit(flow.Event{Type: flow.EventNodeFailed, Node: t.name, Attempt: attempts, Status: runStatus(err), Error: err.Error()})
+		d.err = fmt.Errorf("exec %s failed: %w", t.name, err)
+		return d
+	}
+	var collected []any
+	recorded := false // 本次执行是否已有一轮结果写入状态
+	for iteration := 1; ; iteration++ {
+		iterCtx := nodeCtx
+		if node.LoopCond != nil {
+			s.state.Set(LoopKey(t.name), &ExecutionResult{NodeName: t.name, Data: map[string]any{"iteration": iteration}})
+			iterCtx = context.WithValue(nodeCtx, loopIterationKey{}, iteration)
+		}
+		attempt := 0
+		result, attempts, err = executeWithRetry(iterCtx, node.Retry, func(ctx context.Context) (*ExecutionResult, error) {
+			attempt++
+			s.emit(flow.Event{Type: fn.Ternary(attempt > 1, flow.EventNodeRetried, flow.EventNodeStarted), Node: t.name, Attempt: attempt, Status: flow.TaskRunning})
+			started := time.Now()
+			children := &childRuns{}
+			state := s.state.Snapshot()
+			r, err := invoke(context.WithValue(ctx, childRunsKey{}, children), s.instance(node, state), state)
+			s.trace(t.name, attempt, started, children, r, err)
+			return r, err
+		})
+		if err != nil {
+			return fail(s.classify(err))
+		}
+		var control LoopControl
+		if result != nil {
+			result.Attempts = attempts
+			control = result.Control
+		}
+		if control == LoopContinue && node.LoopCond == nil {
+			return fail(fmt.Errorf("%w: node %s has no LoopCond", ErrContinueOutsideLoop, t.name))
+		}
+		if control == LoopContinue && !recorded {
+			// 还没有任何一轮的结果时写入空结果，分支与下游节点不会拿到 nil
+			empty := &ExecutionResult{NodeName: t.name, Attempts: attempts}
+			if node.Accumulate {
+				empty.Data = []any{}
+			}
+			s.state.Set(t.name, empty)
+			recorded = true
+		}
+		if control != LoopContinue {
+			recorded = true
+			if node.Accumulate && node.LoopCond != nil {
+				// 累积每轮结果：Data 为各轮 Data 组成的列表
+				if result != nil {
+					collected = append(collected, result.Data)
+				}
+				acc := &ExecutionResult{NodeName: t.name, Data: slices.Clone(collected), Attempts: attempts}
+				if result != nil {
+					acc.NodeName, acc.Stream, acc.Raw = result.NodeName, result.Stream, result.Raw
+				}
+				s.state.Set(t.name, acc)
+				result = acc
+			} else {
+				s.state.Set(t.name, result)
+			}
+		}
+		if result != nil && result.Interrupt != nil {
+			// 挂起：不再继续循环与分支
+			d.interrupted = true
+			break
+		}
+		if node.LoopCond == nil || control == LoopBreak || !node.LoopCond(s.state.Snapshot()) {
+			break
+		}
+		if limit := node.maxIterations(); limit > 0 && iteration >= limit {
+			return fail(fmt.Errorf("%w: node %s reached %d iterations", ErrMaxIterations, t.name, limit))
+		}
+	}
+	if result != nil && result.Control == LoopContinue {
+		// 最后一轮被跳过，沿用之前记录的结果
+		result, _ = s.state.Get(t.name)
+	}
+
+	if s.g.Hooks.After != nil {
+		s.g.Hooks.After(t.name, result, err, s.state.Snapshot())
+	}
+
+	d.result = result
+	if d.interrupted {
+		s.emit(flow.Event{Type: flow.EventNodeInterrupted, Node: t.name, Attempt: attempts, Status: flow.TaskInterrupted, Data: result.Interrupt.Prompt})
+		return d
+	}
+	if node.Branch != nil {
+		next := node.Branch(result, s.state.Snapshot())
+		if len(node.BranchTargets) > 0 && next != "END" && !slices.Contains(node.BranchTargets, next) {
+			d.err = fmt.Errorf("node %s branched to undeclared target %q", t.name, next)
+			s.emit(flow.Event{Type: flow.EventNodeFailed, Node: t.name, Attempt: attempts, Status: flow.TaskFailed, Error: d.err.Error()})
+			return d
+		}
+		d.next = []string{next}
+		d.branched = true
+	} else if len(s.g.Conditions[t.name]) > 0 {
+		next, err := s.g.route(t.name, s.state.Snapshot())
+		if err != nil {
+			d.err = err
+			s.emit(flow.Event{Type: flow.EventNodeFailed, Node: t.name, Attempt: attempts, Status: flow.TaskFailed, Error: err.Error()})
+			return d
+		}
+		d.next = next
+		d.branched = true
+	} else {
+		d.next = s.g.Edges[t.name]
+	}
+	s.emit(flow.Event{Type: flow.EventNodeSucceeded, Node: t.name, Attempt: attempts, Status: flow.TaskCompleted})
+	return d
+}
+
+// invoke 执行节点函数；ctx 结束时不再等待节点返回，节点 panic 转为错误
+func invoke(ctx context.Context, node *Node, state ContextMap) (*ExecutionResult, error) {
+	type output struct {
+		result *ExecutionResult
+		err    error
+	}
+	ch := make(chan output, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				ch <- output{err: fmt.Errorf("panic: %v", r)}
+			}
+		}()
+		result, err := node.Execute(ctx, state, node)
+		ch <- output{result: result, err: err}
+	}()
+	select {
+	case out := <-ch:
+		return out.result, out.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// classify 区分运行取消与节点（或单次尝试）超时
+func (s *scheduler) classify(err error) error {
+	switch {
+	case s.parent.Err() != nil:
+		return fmt.Errorf("%w: %w", ErrRunCancelled, err)
+	case s.ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("%w: %w", ErrNodeTimeout, err)
+	}
+	return err
+}
+
+// complete 处理节点完成，返回新就绪的任务
+func (s *scheduler) complete(d taskDone) []task {
+	if d.err != nil {
+		cancelled := s.ctx.Err() != nil && (errors.Is(d.err, context.Canceled) || errors.Is(d.err, ErrRunCancelled))
+		s.failures = append(s.failures, taskFailure{task: d.task, err: d.err, cancelled: cancelled})
+		s.mark(d.lineage, fn.Ternary(cancelled, flow.TaskCancelled, flow.TaskFailed), d.err)
+		if s.g.FailFast {
+			s.cancel()
+		}
+		return s.propagate(d.name, nil, arrivalFailed)
+	}
+
+	if d.interrupted {
+		s.interrupts = append(s.interrupts, PendingInterrupt{
+			Node:   d.name,
+			Token:  interruptToken(s.runID, d.name),
+			Prompt: d.result.Interrupt.Prompt,
+		})
+		s.mark(d.lineage, flow.TaskPending, nil)
+		return nil
+	}
+	s.log = append(s.log, CompletedNode{Node: d.name, Next: d.next, Branched: d.branched})
+
+	var targets []string
+	for _, n := range d.next {
+		if n != "" && n != "END" {
+			targets = append(targets, n)
+		}
+	}
+	if len(targets) == 0 && !slices.Contains(s.terminals, d.name) {
+		s.terminals = append(s.terminals, d.name)
+	}
+	// 多个出边即为并行分支，记录每个分支的结果
+	fan := len(targets) > 1
+	if fan {
+		outcomes := make([]BranchOutcome, len(targets))
+		for i, n := range targets {
+			outcomes[i] = BranchOutcome{Node: n, Status: flow.TaskCompleted}
+		}
+		s.fanouts[d.name] = outcomes
+	}
+
+	var tasks []task
+	for _, n := range targets {
+		lineage := d.lineage
+		if fan {
+			lineage = append(slices.Clip(lineage), branchRef{parent: d.name, child: n})
+		}
+		tasks = append(tasks, s.arrive(d.name, n, lineage)...)
+	}
+	if d.branched {
+		// Branch 未选中的出边通知下游
+		tasks = append(tasks, s.propagate(d.name, targets, arrivalSkipped)...)
+	}
+	return tasks
+}
+
+// arrive 上游 from 完成后到达 to
+func (s *scheduler) arrive(from, to string, lineage []branchRef) []task {
+	if !s.joins.isJoin(to) {
+		return []task{{from: from, name: to, lineage: lineage}}
+	}
+	if prev, ok := s.joinLineage[to]; ok {
+		lineage = commonLineage(prev, lineage)
+	}
+	s.joinLineage[to] = lineage
+	switch s.joins.arrive(from, to, arrivalDone) {
+	case joinRun:
+		return []task{s.fire(from, to)}
+	case joinSkip, joinFail:
+		delete(s.joinLineage, to)
+	}
+	return nil
+}
+
+// fire 汇聚节点满足条件，合并上游结果后调度
+func (s *scheduler) fire(from, to string) task {
+	s.state.Set(JoinKey(to), s.joins.upstream(to, s.state))
+	lineage := s.joinLineage[to]
+	delete(s.joinLineage, to)
+	return task{from: from, name: to, lineage: lineage}
+}
+
+// propagate 沿 name 的出边（taken 除外）传播跳过/失败，直到遇到汇聚节点
+func (s *scheduler) propagate(name string, taken []string, kind arrival) []task {
+	type edge struct {
+		from, to string
+		kind     arrival
+	}
+	var stack []edge
+	for _, n := range s.joins.forward(name) {
+		if !slices.Contains(taken, n) {
+			stack = append(stack, edge{from: name, to: n, kind: kind})
+		}
+	}
+	var tasks []task
+	for len(stack) > 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, ok := s.g.Nodes[e.to]; !ok {
+			continue
+		}
+		next := e.kind
+		if s.joins.isJoin(e.to) {
+			switch s.joins.arrive(e.from, e.to, e.kind) {
+			case joinWait:
+				continue
+			case joinRun:
+				tasks = append(tasks, s.fire(e.from, e.to))
+				continue
+			case joinSkip:
+				next = arrivalSkipped
+			case joinFail:
+				next = arrivalFailed
+			}
+			delete(s.joinLineage, e.to)
+		}
+		s.emit(flow.Event{Type: flow.EventNodeSkipped, Node: e.to, Status: fn.Ternary(next == arrivalFailed, flow.TaskFailed, flow.TaskSkipped)})
+		for _, n := range s.joins.forward(e.to) {
+			stack = append(stack, edge{from: e.to, to: n, kind: next})
+		}
+	}
+	return tasks
+}
+
+// mark 更新任务所在各层并行分支的状态
+func (s *scheduler) mark(lineage []branchRef, status flow.JobStatus, err error) {
+	for _, ref := range lineage {
+		outcomes := s.fanouts[ref.parent]
+		for i := range outcomes {
+			if outcomes[i].Node != ref.child || outcomes[i].Status == flow.TaskFailed {
+				continue
+			}
+			outcomes[i].Status = status
+			if err != nil && outcomes[i].Error == "" {
+				outcomes[i].Error = err.Error()
+			}
+		}
+	}
+}
+
+// finish 将分支结果写回 fan-out 节点的执行结果
+func (s *scheduler) finish() {
+	for parent, outcomes := range s.fanouts {
+		if r, ok := s.state.Get(parent); ok && r != nil {
+			r.Branches = outcomes
+		}
+	}
+}
+
+// err 汇总本次运行的错误：并行分支内的失败按最外层 fan-out 聚合为 ParallelError
+func (s *scheduler) err() error {
+	var errs []error
+	groups := map[string]*ParallelError{}
+	cancelled := s.parent.Err()
+	if cancelled != nil {
+		errs = append(errs, fmt.Errorf("%w: %w", ErrRunCancelled, cancelled))
+	}
+	for _, f := range s.failures {
+		if len(f.lineage) == 0 {
+			if cancelled == nil || !f.cancelled {
+				errs = append(errs, f.err)
+			}
+			continue
+		}
+		root := f.lineage[0]
+		pe, ok := groups[root.parent]
+		if !ok {
+			pe = &ParallelError{Node: root.parent, Branches: s.fanouts[root.parent]}
+			groups[root.parent] = pe
+			errs = append(errs, pe)
+		}
+		pe.errs = append(pe.errs, fmt.Errorf("branch %s: %w", root.child, f.err))
+	}
+	if len(errs) == 0 && len(s.interrupts) > 0 {
+		return &InterruptedError{RunID: s.runID, Interrupts: s.interrupts}
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+// commonLineage 汇聚节点属于所有上游共同所在的分支
+func commonLineage(a, b []branchRef) []branchRef {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n:n]
+}
@@ -0,0 +1,72 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaxConcurrencyLimitsWorkers(t *testing.T) {
+	var running, peak atomic.Int32
+	work := func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+		return SimpleResult(n), nil
+	}
+	g := NewDSLGraph()
+	g.MaxConcurrency = 2
+	g.StartWith("a", value(0))
+	g.Parallel("p", work, work, work, work, work, work).Join("j", value("done"))
+	if _, err := g.RunWithDSL(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if p := peak.Load(); p != 2 {
+		t.Fatalf("peak concurrency %d, want 2", p)
+	}
+}
+
+func TestCompiledGraphConcurrentRuns(t *testing.T) {
+	echo := func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+		return SimpleResult(state["input"].Data), nil
+	}
+	g := NewDSLGraph()
+	g.StartWith("a", echo)
+	g.Parallel("p", echo, echo).Join("j", func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+		return SimpleResult(fmt.Sprint(state["a"].Data, state["p_p0"].Data, state["p_p1"].Data)), nil
+	})
+	c, err := g.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 32)
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res, err := c.Run(context.Background(), ContextMap{"input": SimpleResult(i)})
+			if err != nil {
+				errs <- err
+				return
+			}
+			if want := fmt.Sprint(i, i, i); res.State["j"].Data != want {
+				errs <- fmt.Errorf("run %d got %v, want %s", i, res.State["j"].Data, want)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,79 @@
+package workflow
+
+import (
+	"context"
+	"sync"
+)
+
+// State 线程安全的执行状态，引擎对 ContextMap 的所有读写都经过它。
+// 节点函数、分支函数和 Hooks 收到的 ContextMap 都是快照，修改快照不会影响运行状态；
+// 需要在节点内写入状态时，通过 StateFromContext(ctx) 获取 State 再调用 Set。
+type State struct {
+	mu     sync.RWMutex
+	data   ContextMap
+	closed bool // 运行结束后忽略写入，避免超时后仍在执行的节点改写调用方的 map
+}
+
+// NewState 包装调用方传入的 ContextMap，运行结束后结果仍写在该 map 中
+func NewState(data ContextMap) *State {
+	if data == nil {
+		data = make(ContextMap)
+	}
+	return &State{data: data}
+}
+
+func (s *State) Get(name string) (*ExecutionResult, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.data[name]
+	return r, ok
+}
+
+func (s *State) Set(name string, result *ExecutionResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.data[name] = result
+}
+
+func (s *State) Delete(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	delete(s.data, name)
+}
+
+// close 运行结束后调用，之后的写入被丢弃
+func (s *State) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+}
+
+// Snapshot 返回当前状态的浅拷贝
+func (s *State) Snapshot() ContextMap {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cp := make(ContextMap, len(s.data))
+	for k, v := range s.data {
+		cp[k] = v
+	}
+	return cp
+}
+
+type stateKey struct{}
+
+// WithState 将 State 放入 context，供节点内部读写
+func WithState(ctx context.Context, s *State) context.Context {
+	return context.WithValue(ctx, stateKey{}, s)
+}
+
+// StateFromContext 获取当前运行的 State，不在引擎内运行时返回 nil
+func StateFromContext(ctx context.Context) *State {
+	s, _ := ctx.Value(stateKey{}).(*State)
+	return s
+}
@@ -0,0 +1,56 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestParallelBranchesWriteStateConcurrently(t *testing.T) {
+	write := func(i int) NodeFunc {
+		return func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+			st := StateFromContext(ctx)
+			for j := 0; j < 100; j++ {
+				st.Set(fmt.Sprintf("w%d_%d", i, j), SimpleResult(j))
+				st.Snapshot()
+			}
+			state["ignored"] = SimpleResult(i) // 修改快照不影响运行状态
+			return SimpleResult(i), nil
+		}
+	}
+	fns := make([]NodeFunc, 8)
+	for i := range fns {
+		fns[i] = write(i)
+	}
+	g := NewDSLGraph()
+	g.StartWith("a", value(0))
+	g.Parallel("p", fns...).Join("j", value("done"))
+	initial := ContextMap{}
+	res, err := g.RunWithDSL(context.Background(), initial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := res.State["w7_99"]; !ok {
+		t.Fatal("write through State was lost")
+	}
+	if _, ok := res.State["ignored"]; ok {
+		t.Fatal("snapshot write leaked into run state")
+	}
+	if _, ok := initial["j"]; !ok {
+		t.Fatal("results were not written to the caller's map")
+	}
+}
+
+func TestStateIgnoresWritesAfterClose(t *testing.T) {
+	s := NewState(nil)
+	s.Set("a", SimpleResult(1))
+	s.close()
+	s.Set("b", SimpleResult(2))
+	s.Delete("a")
+	if _, ok := s.Get("a"); !ok {
+		t.Fatal("delete after close took effect")
+	}
+	if _, ok := s.Get("b"); ok {
+		t.Fatal("set after close took effect")
+	}
+}
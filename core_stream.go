@@ -0,0 +1,38 @@
+package workflow
+
+import "context"
+
+// StreamWriter 节点的流式输出：每个分片实时以 stream_chunk 事件推送给订阅方，
+// 同时收集起来，节点结束时通过 Result 生成供下游使用的聚合结果。
+type StreamWriter struct {
+	ctx    context.Context
+	chunks []any
+}
+
+func NewStreamWriter(ctx context.Context) *StreamWriter {
+	return &StreamWriter{ctx: ctx}
+}
+
+// Write 推送并记录一个分片
+func (w *StreamWriter) Write(chunk any) {
+	w.chunks = append(w.chunks, chunk)
+	EmitChunk(w.ctx, chunk)
+}
+
+func (w *StreamWriter) Chunks() []any {
+	return w.chunks
+}
+
+// Result Data 为 aggregate 合并后的结果（aggregate 为空时即全部分片），Raw 保留原始分片。
+// 结果标记为 Stream，只应在输出确实是逐片产生时使用；一次性得到的输出直接返回普通结果。
+func (w *StreamWriter) Result(aggregate func(chunks []any) any) *ExecutionResult {
+	var data any = w.chunks
+	if aggregate != nil {
+		data = aggregate(w.chunks)
+	}
+	return &ExecutionResult{
+		Data:   data,
+		Stream: true,
+		Raw:    w.chunks,
+	}
+}
@@ -0,0 +1,44 @@
+package workflow
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ninenhan/go-workflow/flow"
+)
+
+func TestStreamWriterEmitsAndAggregates(t *testing.T) {
+	var chunks []any
+	g := NewDSLGraph()
+	g.OnEvent(func(evt flow.Event) {
+		if evt.Type == flow.EventStreamChunk && evt.Node == "s" {
+			chunks = append(chunks, evt.Data)
+		}
+	})
+	g.StartWith("s", func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+		w := NewStreamWriter(ctx)
+		for _, part := range []string{"a", "b", "c"} {
+			w.Write(part)
+		}
+		return w.Result(func(chunks []any) any {
+			var sb strings.Builder
+			for _, c := range chunks {
+				sb.WriteString(c.(string))
+			}
+			return sb.String()
+		}), nil
+	}).Then("next", func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+		return SimpleResult(state["s"].Data), nil
+	})
+	res, err := g.RunWithDSL(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("want 3 chunk events, got %v", chunks)
+	}
+	if !res.State["s"].Stream || res.State["next"].Data != "abc" {
+		t.Fatalf("downstream did not see aggregated result: %v", res.State["next"].Data)
+	}
+}
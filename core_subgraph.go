@@ -0,0 +1,144 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrSubgraphInterrupted 子图中的节点请求了人工介入：子图没有可供父运行恢复的检查点，按节点失败处理
+var ErrSubgraphInterrupted = errors.New("interrupts are not supported inside subgraphs")
+
+// Subgraph 将另一个 Graph 作为单个节点执行。
+// 子图使用独立的状态：只有 Inputs 映射的 key 会从父状态拷贝进去，只有 Outputs 映射的 key 会写回父状态。
+type Subgraph struct {
+	Graph   *Graph `json:"-"`                 // 直接引用的图，优先于 Name
+	Name    string `json:"name,omitempty"`    // 通过 RegisterGraph 注册的图
+	Version string `json:"version,omitempty"` // 为空时取最后注册的版本
+	// Inputs 子图状态 key -> 父状态 key
+	Inputs map[string]string `json:"inputs,omitempty"`
+	// Outputs 父状态 key -> 子图状态 key
+	Outputs map[string]string `json:"outputs,omitempty"`
+}
+
+type graphEntry struct {
+	versions map[string]*Graph
+	latest   string
+}
+
+var (
+	graphsMu sync.RWMutex
+	graphs   = map[string]*graphEntry{}
+)
+
+// RegisterGraph 注册可被 Subgraph 按名称与版本引用的图
+func RegisterGraph(name, version string, g *Graph) {
+	graphsMu.Lock()
+	defer graphsMu.Unlock()
+	e, ok := graphs[name]
+	if !ok {
+		e = &graphEntry{versions: map[string]*Graph{}}
+		graphs[name] = e
+	}
+	e.versions[version] = g
+	e.latest = version
+}
+
+// FindGraph version 为空时返回最后注册的版本
+func FindGraph(name, version string) (*Graph, bool) {
+	graphsMu.RLock()
+	defer graphsMu.RUnlock()
+	e, ok := graphs[name]
+	if !ok {
+		return nil, false
+	}
+	if version == "" {
+		version = e.latest
+	}
+	g, ok := e.versions[version]
+	return g, ok
+}
+
+func (sg *Subgraph) resolve() (*Graph, error) {
+	if sg.Graph != nil {
+		return sg.Graph, nil
+	}
+	if g, ok := FindGraph(sg.Name, sg.Version); ok {
+		return g, nil
+	}
+	if sg.Version != "" {
+		return nil, fmt.Errorf("graph %s@%s is not registered", sg.Name, sg.Version)
+	}
+	return nil, fmt.Errorf("graph %s is not registered", sg.Name)
+}
+
+type childRunsKey struct{}
+
+// childRuns 收集一次节点尝试中启动的子图运行，写入父运行的 Trace
+type childRuns struct {
+	mu   sync.Mutex
+	runs []*RunResult
+}
+
+func (c *childRuns) add(r *RunResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.runs = append(c.runs, r)
+}
+
+func (c *childRuns) list() []*RunResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.runs
+}
+
+// Execute 运行子图，返回结果的 Data 为子图终止节点的输出（节点名 -> Data）。
+// 子图的运行 ID 为 "父运行 ID/节点名"。子图内不支持 Interrupt，挂起的子图返回 ErrSubgraphInterrupted。
+func (sg *Subgraph) Execute(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+	g, err := sg.resolve()
+	if err != nil {
+		return nil, err
+	}
+	initial := ContextMap{}
+	for childKey, parentKey := range sg.Inputs {
+		if r, ok := state[parentKey]; ok && r != nil {
+			cp := *r
+			initial[childKey] = &cp
+		}
+	}
+	name, _ := ctx.Value(nodeNameKey{}).(string)
+	if name == "" {
+		name = self.Name
+	}
+	childCtx := WithRunID(ctx, RunIDFromContext(ctx)+"/"+name)
+	c, err := g.Compile()
+	if err != nil {
+		return nil, fmt.Errorf("subgraph %s: %w", name, err)
+	}
+	result, err := c.Run(childCtx, initial)
+	if runs, ok := ctx.Value(childRunsKey{}).(*childRuns); ok && result != nil {
+		runs.add(result)
+	}
+	if errors.Is(err, ErrInterrupted) {
+		// 不包装 InterruptedError，父运行不会被误报为可恢复的挂起
+		return nil, fmt.Errorf("subgraph %s: %w: %v", name, ErrSubgraphInterrupted, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("subgraph %s: %w", name, err)
+	}
+	if parent := StateFromContext(ctx); parent != nil {
+		for parentKey, childKey := range sg.Outputs {
+			if r, ok := result.State[childKey]; ok {
+				parent.Set(parentKey, r)
+			}
+		}
+	}
+	outputs := make(map[string]any, len(result.Outputs))
+	for n, r := range result.Outputs {
+		if r != nil {
+			outputs[n] = r.Data
+		}
+	}
+	return &ExecutionResult{NodeName: name, Data: outputs}, nil
+}
@@ -0,0 +1,64 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ninenhan/go-workflow/flow"
+)
+
+func TestSubgraphMapsInputsAndOutputs(t *testing.T) {
+	child := NewDSLGraph()
+	child.StartWith("double", func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+		return SimpleResult(state["n"].Data.(int) * 2), nil
+	})
+	g := NewDSLGraph()
+	g.StartWith("a", value(21))
+	g.AddNode("sub", &Node{Subgraph: &Subgraph{
+		Graph:   child,
+		Inputs:  map[string]string{"n": "a"},
+		Outputs: map[string]string{"doubled": "double"},
+	}})
+	g.AddEdge("a", "sub")
+	res, err := g.RunWithDSL(WithRunID(context.Background(), "parent"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.State["doubled"].Data != 42 || res.State["sub"].Data.(map[string]any)["double"] != 42 {
+		t.Fatalf("unexpected outputs %v %v", res.State["doubled"], res.State["sub"].Data)
+	}
+	if _, ok := res.State["n"]; ok {
+		t.Fatal("child state leaked into parent")
+	}
+	var children []*RunResult
+	for _, e := range res.Trace {
+		children = append(children, e.Children...)
+	}
+	if len(children) != 1 || children[0].RunID != "parent/sub" {
+		t.Fatalf("unexpected child runs %+v", children)
+	}
+}
+
+func TestSubgraphRejectsInterrupts(t *testing.T) {
+	child := NewDSLGraph()
+	child.StartWith("ask", approval)
+	var failed []string
+	g := NewDSLGraph()
+	g.OnEvent(func(evt flow.Event) {
+		if evt.Type == flow.EventNodeFailed {
+			failed = append(failed, evt.Node)
+		}
+	})
+	g.AddNode("sub", &Node{Subgraph: &Subgraph{Graph: child}})
+	res, err := g.Run(context.Background(), "sub", nil)
+	if !errors.Is(err, ErrSubgraphInterrupted) || errors.Is(err, ErrInterrupted) {
+		t.Fatalf("want ErrSubgraphInterrupted only, got %v", err)
+	}
+	if res.Status != flow.TaskFailed || len(res.Interrupts) != 0 {
+		t.Fatalf("unexpected result %s %+v", res.Status, res.Interrupts)
+	}
+	if res.Trace[0].Status != flow.TaskFailed || len(failed) != 1 {
+		t.Fatalf("trace %s and events %v disagree", res.Trace[0].Status, failed)
+	}
+}
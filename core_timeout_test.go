@@ -0,0 +1,60 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// blocking 阻塞直到 ctx 取消
+func blocking(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestNodeTimeout(t *testing.T) {
+	g := NewDSLGraph()
+	g.StartWith("a", blocking)
+	g.Nodes["a"].Timeout = 20 * time.Millisecond
+	_, err := g.RunWithDSL(context.Background(), nil)
+	if !errors.Is(err, ErrNodeTimeout) {
+		t.Fatalf("want ErrNodeTimeout, got %v", err)
+	}
+}
+
+func TestRunTimeoutCancelsNodes(t *testing.T) {
+	g := NewDSLGraph()
+	g.Timeout = 20 * time.Millisecond
+	g.StartWith("a", blocking)
+	begin := time.Now()
+	_, err := g.RunWithDSL(context.Background(), nil)
+	if !errors.Is(err, ErrRunCancelled) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want cancelled run, got %v", err)
+	}
+	if time.Since(begin) > time.Second {
+		t.Fatal("run did not stop at its timeout")
+	}
+}
+
+func TestAbandonedNodeCannotWriteState(t *testing.T) {
+	release := make(chan struct{})
+	done := make(chan struct{})
+	g := NewDSLGraph()
+	g.StartWith("a", func(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+		<-release
+		StateFromContext(ctx).Set("late", SimpleResult(1))
+		close(done)
+		return SimpleResult(1), nil
+	})
+	g.Nodes["a"].Timeout = 10 * time.Millisecond
+	initial := ContextMap{}
+	if _, err := g.RunWithDSL(context.Background(), initial); err == nil {
+		t.Fatal("want timeout")
+	}
+	close(release)
+	<-done
+	if _, ok := initial["late"]; ok {
+		t.Fatal("abandoned node wrote into the caller's map")
+	}
+}
@@ -80,7 +80,11 @@ func DagGraphTests() {
 	//graph.AddEdge("judge", "parallel1")
 	//graph.AddEdge("judge", "parallel2")
 
-	result := make(core.ContextMap)
-	_ = graph.Run(context.Background(), "input", result)
-	fmt.Printf("DAG Graph Tests result : %v\n", fn.Stringify(result))
+	result, err := graph.Run(context.Background(), "input", core.ContextMap{})
+	if err != nil {
+		fmt.Println("Run error:", err)
+	}
+	if result != nil {
+		fmt.Printf("DAG Graph Tests result : %v\n", fn.Stringify(result))
+	}
 }
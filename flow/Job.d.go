@@ -7,4 +7,8 @@ const (
 	TaskRunning   JobStatus = "RUNNING"
 	TaskCompleted JobStatus = "COMPLETED"
 	TaskFailed    JobStatus = "FAILED"
+	TaskCancelled JobStatus = "CANCELLED"
+	TaskSkipped   JobStatus = "SKIPPED"
+	// TaskInterrupted 等待人工输入
+	TaskInterrupted JobStatus = "INTERRUPTED"
 )
@@ -2,9 +2,12 @@ package flow
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/ninenhan/go-workflow/fn"
+	"log/slog"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
@@ -47,6 +50,40 @@ type Condition struct {
 	Children  []Condition    `json:"children,omitempty"`
 }
 
+// String 条件的可读描述，用于流程图等展示
+func (c Condition) String() string {
+	if c.Label != "" {
+		return c.Label
+	}
+	if len(c.Children) > 0 {
+		parts := make([]string, 0, len(c.Children))
+		for _, child := range c.Children {
+			parts = append(parts, child.String())
+		}
+		switch c.Connector {
+		case OR:
+			return "(" + strings.Join(parts, " OR ") + ")"
+		case NOT:
+			return "NOT (" + strings.Join(parts, " AND ") + ")"
+		}
+		return "(" + strings.Join(parts, " AND ") + ")"
+	}
+	if c.Script != "" {
+		return c.Script
+	}
+	if c.Key == "" {
+		return ""
+	}
+	op := c.Operator
+	if op == "" {
+		op = EQ.Value
+	}
+	if c.Value == nil {
+		return fmt.Sprintf("%s %s", c.Key, op)
+	}
+	return fmt.Sprintf("%s %s %v", c.Key, op, c.Value)
+}
+
 // IfUnit 实现 if–else 控制，根据条件选择执行 true 或 false 分支中的单元
 type IfUnit struct {
 	BaseUnit
@@ -61,6 +98,12 @@ func (t *IfUnit) GetUnitName() string {
 	return reflect.TypeOf(IfUnit{}).Name()
 }
 
+func (t *IfUnit) Describe(d *UnitDescriptor) {
+	d.DisplayName = "条件分支"
+	d.Category = "控制"
+	d.Description = "按条件依次执行 if / else if / else 分支"
+}
+
 func (t *IfUnit) Execute(ctx *PipelineContext, i *Input) (*Output, error) {
 	return nil, nil
 }
@@ -126,27 +169,140 @@ func (t *IfUnit) UnmarshalJSON(data []byte) error {
 }
 
 // WhileUnit 循环单元 =====
+// 条件成立时执行 Units，执行完后再次判断条件。循环状态写在 Env[ID]（ID 为空时自动生成）：
+// {"iteration": 当前轮次（从 1 开始）, "running": 是否继续}，Units 中可通过 {{ID.iteration}} 引用；
+// Accumulate 为 true 时 "results" 按轮次保存循环体各单元的输出（单元 ID -> Data）。
 type WhileUnit struct {
 	BaseUnit
-	Condition Condition   `json:"condition,omitempty"`
-	Units     []PhaseUnit `json:"units,omitempty"`
+	Condition     Condition   `json:"condition,omitempty"`
+	Units         []PhaseUnit `json:"units,omitempty"`
+	MaxIterations int         `json:"max_iterations,omitempty"` // 0 取 DefaultMaxIterations，<0 不限制
+	Accumulate    bool        `json:"accumulate,omitempty"`
+}
+
+// DefaultMaxIterations 未设置 MaxIterations 时的轮数上限
+const DefaultMaxIterations = 10000
+
+var ErrMaxIterations = errors.New("loop exceeded max iterations")
+
+// BreakLoop 在循环体内调用，跳过本轮剩余的单元并结束 id 对应的循环
+func BreakLoop(ctx *PipelineContext, id string) {
+	setLoopFlag(ctx, id, "break")
 }
 
+// ContinueLoop 在循环体内调用，跳过本轮剩余的单元，回到 id 对应循环的条件判断
+func ContinueLoop(ctx *PipelineContext, id string) {
+	setLoopFlag(ctx, id, "continue")
+}
+
+func setLoopFlag(ctx *PipelineContext, id, flag string) {
+	if state, ok := ctx.Env[id].(map[string]any); ok {
+		state[flag] = true
+	}
+}
+
+func (t *WhileUnit) GetUnitName() string {
+	return reflect.TypeOf(WhileUnit{}).Name()
+}
+
+func (t *WhileUnit) Describe(d *UnitDescriptor) {
+	d.DisplayName = "循环"
+	d.Category = "控制"
+	d.Description = "条件成立时重复执行循环体，可通过 BreakLoop / ContinueLoop 结束循环或跳过本轮"
+}
+
+// Execute 结束上一轮并判断是否进入下一轮，超过轮数上限时返回 ErrMaxIterations
 func (t *WhileUnit) Execute(ctx *PipelineContext, i *Input) (*Output, error) {
+	if t.ID == "" {
+		t.PresetID()
+	}
+	iteration := 0
+	results := []any{}
+	state, _ := ctx.Env[t.ID].(map[string]any)
+	if running, _ := state["running"].(bool); running {
+		iteration, _ = state["iteration"].(int)
+		if prev, ok := state["results"].([]any); ok {
+			results = prev
+		}
+		current, _ := state["current"].(map[string]any)
+		if current == nil {
+			current = map[string]any{}
+		}
+		results = append(results, current)
+	}
+	stop, _ := state["break"].(bool)
+	running := !stop && ConditionValidator(ctx, t.Condition)
+	if running {
+		iteration++
+		limit := t.MaxIterations
+		if limit == 0 {
+			limit = DefaultMaxIterations
+		}
+		if limit > 0 && iteration > limit {
+			return nil, fmt.Errorf("%w: unit %s reached %d iterations", ErrMaxIterations, t.ID, limit)
+		}
+	}
+	next := map[string]any{"iteration": iteration, "running": running}
+	if t.Accumulate {
+		next["results"] = results
+	}
+	ctx.Env[t.ID] = next
 	return nil, nil
 }
 
+// Next 继续循环时依次执行 Units，再回到自身重新判断
 func (t *WhileUnit) Next(ctx *PipelineContext, i *Input) []PhaseUnit {
 	if t == nil || ctx == nil {
 		return nil
 	}
-	if t.Condition.Operator == "" {
-		t.Condition.Operator = EQ.Value
+	state, _ := ctx.Env[t.ID].(map[string]any)
+	if running, _ := state["running"].(bool); !running {
+		return nil
 	}
-	if ConditionValidator(ctx, t.Condition) {
-		return SafeUnits(t.Units)
+	next := t.steps(PrepareUnits(t.Units))
+	return append(next, t)
+}
+
+// steps 将循环体中的单元（包括其 Next 展开的单元）包装为 loopStep
+func (t *WhileUnit) steps(units []PhaseUnit) []PhaseUnit {
+	steps := make([]PhaseUnit, 0, len(units))
+	for _, u := range units {
+		steps = append(steps, &loopStep{PhaseUnit: u, loop: t})
 	}
-	return nil
+	return steps
+}
+
+// loopStep 循环体中的单元：所属循环 break 或 continue 后跳过，Accumulate 时记录本轮输出
+type loopStep struct {
+	PhaseUnit
+	loop *WhileUnit
+}
+
+func (s *loopStep) skipped(ctx *PipelineContext) bool {
+	state, _ := ctx.Env[s.loop.ID].(map[string]any)
+	stop, _ := state["break"].(bool)
+	skip, _ := state["continue"].(bool)
+	return stop || skip
+}
+
+func (s *loopStep) Execute(ctx *PipelineContext, i *Input) (*Output, error) {
+	res, err := s.PhaseUnit.Execute(ctx, i)
+	if err != nil || res == nil || !s.loop.Accumulate || s.GetID() == "" {
+		return res, err
+	}
+	if state, ok := ctx.Env[s.loop.ID].(map[string]any); ok {
+		current, _ := state["current"].(map[string]any)
+		if current == nil {
+			current = map[string]any{}
+			state["current"] = current
+		}
+		current[s.GetID()] = res.Data
+	}
+	return res, nil
+}
+
+func (s *loopStep) Next(ctx *PipelineContext, i *Input) []PhaseUnit {
+	return s.loop.steps(s.PhaseUnit.Next(ctx, i))
 }
 
 func (t *WhileUnit) UnmarshalJSON(data []byte) error {
@@ -171,8 +327,8 @@ func (t *WhileUnit) UnmarshalJSON(data []byte) error {
 }
 
 func CompareNumeric(op, ks, vs string) bool {
-	kf, ok1 := fn.ToFloat64(ks)
-	vf, ok2 := fn.ToFloat64(vs)
+	kf, ok1 := parseNumber(ks)
+	vf, ok2 := parseNumber(vs)
 	if !ok1 || !ok2 {
 		return false
 	}
@@ -194,6 +350,12 @@ func CompareNumeric(op, ks, vs string) bool {
 	}
 }
 
+// parseNumber 比较的两侧都已渲染为字符串，按十进制数解析
+func parseNumber(s string) (float64, bool) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return f, err == nil
+}
+
 func Eval(o string, k string, v string) bool {
 	switch o {
 	case IN.Value:
@@ -213,30 +375,77 @@ func Eval(o string, k string, v string) bool {
 	case EQ.Value, NE.Value, GT.Value, GTE.Value, LT.Value, LTE.Value:
 		return CompareNumeric(o, k, v)
 	case BETWEEN.Value:
-		kf, ok1 := fn.ToFloat64(k)
+		kf, ok1 := parseNumber(k)
 		tuple := strings.Split(v, ",")
 		if !ok1 || len(tuple) != 2 {
 			return false
 		}
-		lower, ok3 := fn.ToFloat64(tuple[0])
-		upper, ok4 := fn.ToFloat64(tuple[1])
+		lower, ok3 := parseNumber(tuple[0])
+		upper, ok4 := parseNumber(tuple[1])
 		return ok3 && ok4 && kf >= lower && kf <= upper
 	}
 	return false
 }
 
 func ConditionValidator(ctx *PipelineContext, condition Condition) bool {
+	ok, err := MatchCondition(condition, ctx.Env)
+	if err != nil {
+		slog.Warn("条件计算失败", "key", condition.Key, "err", err)
+	}
+	return ok
+}
+
+// MatchCondition 按条件树计算：有 Children 时按 Connector 组合子条件（NOT 为子条件全部成立后取反），
+// Script 非空时作为 govaluate 表达式计算，否则用 Operator 比较 Key 与 Value。
+// Key 与字符串 Value 支持 {{path}} 模板；Key 不含模板时按路径从 model 取值。
+func MatchCondition(condition Condition, model map[string]any) (bool, error) {
+	if len(condition.Children) > 0 {
+		all, some := true, false
+		for _, child := range condition.Children {
+			ok, err := MatchCondition(child, model)
+			if err != nil {
+				return false, err
+			}
+			all = all && ok
+			some = some || ok
+		}
+		switch condition.Connector {
+		case OR:
+			return some, nil
+		case NOT:
+			return !all, nil
+		}
+		return all, nil
+	}
+	if condition.Script != "" {
+		return fn.EvalBool(condition.Script, model)
+	}
 	if condition.Operator == "" {
 		condition.Operator = EQ.Value
 	}
-	renderModel := ctx.Env
-	k, o, v := condition.Key, condition.Operator, condition.Value
-	parsed1, _ := fn.ParseTemplate(k)
-	k = fn.RenderTemplateStrictly(k, parsed1, renderModel, false)
-	if s, ok := v.(string); ok {
-		parsed2, _ := fn.ParseTemplate(s)
-		s = fn.RenderTemplateStrictly(k, parsed2, renderModel, false)
-		return Eval(o, k, s)
+	k := renderOperand(condition.Key, model, true)
+	var v string
+	switch value := condition.Value.(type) {
+	case nil:
+	case string:
+		v = renderOperand(value, model, false)
+	case []any, []string:
+		v = strings.Join(fn.ParseList(value), ",")
+	default:
+		v = fmt.Sprint(value)
 	}
-	return false
+	return Eval(condition.Operator, k, v), nil
+}
+
+func renderOperand(s string, model map[string]any, lookup bool) string {
+	parsed, _ := fn.ParseTemplate(s)
+	if len(parsed) > 0 {
+		return fn.RenderTemplateStrictly(s, parsed, model, false)
+	}
+	if lookup {
+		if value := fn.GetValue(model, s); value != nil {
+			return fmt.Sprint(value)
+		}
+	}
+	return s
 }
@@ -0,0 +1,206 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// recordUnit 执行时把 ID 记入 log，fn 非空时其返回值作为输出
+type recordUnit struct {
+	BaseUnit
+	log *[]string
+	fn  func(ctx *PipelineContext) any
+}
+
+func (u *recordUnit) GetUnitName() string {
+	return "recordUnit"
+}
+
+func (u *recordUnit) Execute(ctx *PipelineContext, input *Input) (*Output, error) {
+	*u.log = append(*u.log, u.ID)
+	if u.fn == nil {
+		return &Output{Data: u.ID}, nil
+	}
+	return &Output{Data: u.fn(ctx)}, nil
+}
+
+func record(id string, log *[]string, fn func(ctx *PipelineContext) any) *recordUnit {
+	return &recordUnit{BaseUnit: BaseUnit{ID: id}, log: log, fn: fn}
+}
+
+func runUnits(t *testing.T, env map[string]any, units ...PhaseUnit) error {
+	t.Helper()
+	p := NewPipeline(units)
+	if env != nil {
+		p.Context.Env = env
+	}
+	return p.RunContext(context.Background())
+}
+
+func TestIfUnitConditions(t *testing.T) {
+	cases := []struct {
+		name string
+		cond Condition
+		env  map[string]any
+		want string
+	}{
+		{"template key", Condition{Key: "{{status}}", Operator: SAME.Value, Value: "ok"}, map[string]any{"status": "ok"}, "yes"},
+		{"template key not met", Condition{Key: "{{status}}", Operator: SAME.Value, Value: "ok"}, map[string]any{"status": "failed"}, "no"},
+		{"literal key", Condition{Key: "ok", Operator: SAME.Value, Value: "ok"}, map[string]any{}, "yes"},
+		{"path key", Condition{Key: "user.age", Operator: GT.Value, Value: 18}, map[string]any{"user": map[string]any{"age": 20}}, "yes"},
+		{"path key not met", Condition{Key: "user.age", Operator: GT.Value, Value: 18}, map[string]any{"user": map[string]any{"age": 16}}, "no"},
+		{"numeric string", Condition{Key: "{{score}}", Operator: GT.Value, Value: "3"}, map[string]any{"score": "5"}, "yes"},
+		{"default EQ", Condition{Key: "{{score}}", Value: 5}, map[string]any{"score": "5.0"}, "yes"},
+		{"not a number", Condition{Key: "{{score}}", Operator: GT.Value, Value: 3}, map[string]any{"score": "abc"}, "no"},
+		{"between", Condition{Key: "{{score}}", Operator: BETWEEN.Value, Value: "1,5"}, map[string]any{"score": 3}, "yes"},
+	}
+	for _, c := range cases {
+		var log []string
+		unit := &IfUnit{
+			IfCondition: c.cond,
+			IfUnits:     []PhaseUnit{record("yes", &log, nil)},
+			ElseUnits:   []PhaseUnit{record("no", &log, nil)},
+		}
+		if err := runUnits(t, c.env, unit); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if len(log) != 1 || log[0] != c.want {
+			t.Errorf("%s: ran %v, want %s", c.name, log, c.want)
+		}
+	}
+}
+
+func loopIteration(ctx *PipelineContext, id string) int {
+	state, _ := ctx.Env[id].(map[string]any)
+	iteration, _ := state["iteration"].(int)
+	return iteration
+}
+
+func TestWhileUnitMaxIterations(t *testing.T) {
+	var log []string
+	loop := &WhileUnit{
+		BaseUnit:      BaseUnit{ID: "loop"},
+		Condition:     Condition{Script: "true"},
+		Units:         []PhaseUnit{record("body", &log, nil)},
+		MaxIterations: 3,
+	}
+	err := runUnits(t, nil, loop)
+	if !errors.Is(err, ErrMaxIterations) {
+		t.Fatalf("want ErrMaxIterations, got %v", err)
+	}
+	if len(log) != 3 {
+		t.Fatalf("body ran %d times, want 3", len(log))
+	}
+}
+
+func TestWhileUnitBreakStopsAtBreakPoint(t *testing.T) {
+	var log []string
+	loop := &WhileUnit{
+		BaseUnit:  BaseUnit{ID: "loop"},
+		Condition: Condition{Script: "true"},
+		Units: []PhaseUnit{
+			record("a", &log, func(ctx *PipelineContext) any {
+				if loopIteration(ctx, "loop") == 2 {
+					BreakLoop(ctx, "loop")
+				}
+				return nil
+			}),
+			record("b", &log, nil),
+		},
+	}
+	p := NewPipeline([]PhaseUnit{loop, record("after", &log, nil)})
+	if err := p.RunContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b", "a", "after"}; !slices.Equal(log, want) {
+		t.Fatalf("ran %v, want %v", log, want)
+	}
+	if n := loopIteration(p.Context, "loop"); n != 2 {
+		t.Fatalf("iteration %d, want 2", n)
+	}
+}
+
+func TestWhileUnitContinueAndAccumulate(t *testing.T) {
+	var log []string
+	loop := &WhileUnit{
+		BaseUnit:   BaseUnit{ID: "loop"},
+		Condition:  Condition{Script: "true"},
+		Accumulate: true,
+		Units: []PhaseUnit{
+			record("a", &log, func(ctx *PipelineContext) any {
+				if loopIteration(ctx, "loop") == 1 {
+					ContinueLoop(ctx, "loop")
+				}
+				return loopIteration(ctx, "loop")
+			}),
+			record("b", &log, func(ctx *PipelineContext) any {
+				if loopIteration(ctx, "loop") == 3 {
+					BreakLoop(ctx, "loop")
+				}
+				return loopIteration(ctx, "loop") * 10
+			}),
+		},
+	}
+	p := NewPipeline([]PhaseUnit{loop})
+	if err := p.RunContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "a", "b", "a", "b"}; !slices.Equal(log, want) {
+		t.Fatalf("ran %v, want %v", log, want)
+	}
+	results := p.Context.Env["loop"].(map[string]any)["results"]
+	want := []any{
+		map[string]any{"a": 1},
+		map[string]any{"a": 2, "b": 20},
+		map[string]any{"a": 3, "b": 30},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Fatalf("results %v, want %v", results, want)
+	}
+}
+
+func TestNestedWhileUnitsWithoutIDs(t *testing.T) {
+	var log []string
+	count := func(key string) func(ctx *PipelineContext) any {
+		return func(ctx *PipelineContext) any {
+			n, _ := ctx.Env[key].(float64)
+			ctx.Env[key] = n + 1
+			return nil
+		}
+	}
+	inner := &WhileUnit{
+		Condition: Condition{Script: "inner < 3"},
+		Units:     []PhaseUnit{record("inner-body", &log, count("inner"))},
+	}
+	outer := &WhileUnit{
+		Condition: Condition{Script: "outer < 2"},
+		Units: []PhaseUnit{
+			record("reset", &log, func(ctx *PipelineContext) any {
+				ctx.Env["inner"] = float64(0)
+				return nil
+			}),
+			inner,
+			record("outer-body", &log, count("outer")),
+		},
+	}
+	// 不经过 NewPipeline，两个循环都没有预设 ID
+	p := &Pipeline{Units: []PhaseUnit{outer}, Context: &PipelineContext{Env: map[string]any{"outer": float64(0)}}}
+	if err := p.RunContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var bodies int
+	for _, id := range log {
+		if id == "inner-body" {
+			bodies++
+		}
+	}
+	if bodies != 6 || p.Context.Env["outer"] != float64(2) {
+		t.Fatalf("inner body ran %d times, outer %v: %v", bodies, p.Context.Env["outer"], log)
+	}
+	if outer.ID == "" || inner.ID == "" || outer.ID == inner.ID {
+		t.Fatalf("loops share state key %q", outer.ID)
+	}
+}
@@ -9,14 +9,21 @@ import (
 	"log/slog"
 	"reflect"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type PipeStatus struct {
 	Total  int32     `json:"total,omitempty"`
 	Step   string    `json:"step,omitempty"`
-	Status JobStatus `json:"status,omitempty"` // 运行状态
+	Status JobStatus `json:"status,omitempty"` // 运行状态，结束后为 COMPLETED、FAILED、INTERRUPTED 或 CANCELLED
+	Reason string    `json:"reason,omitempty"` // 中断原因
+	Error  string    `json:"error,omitempty"`
 }
 
+// ErrPipelineInterrupted 通过 Interrupt 中断时 Run 返回的错误
+var ErrPipelineInterrupted = errors.New("pipeline interrupted")
+
 // PipelineContext 用于保存执行过程中的环境变量
 type PipelineContext struct {
 	Env        map[string]any                                `json:"env,omitempty"`
@@ -96,6 +103,10 @@ func (u *BaseUnit) PresetID() {
 func (u *BaseUnit) GetIOConfig() *IOConfig {
 	return u.IOConfig
 }
+
+func (u *BaseUnit) SetIOConfig(cfg *IOConfig) {
+	u.IOConfig = cfg
+}
 func (u *BaseUnit) GetUnitName() string {
 	return u.UnitName
 }
@@ -108,9 +119,18 @@ func (u *BaseUnit) Next(ctx *PipelineContext, i *Input) []PhaseUnit {
 	return nil
 }
 
+// skipper 由 Pipeline 在执行前询问是否跳过，如循环 break/continue 之后循环体中剩余的单元
+type skipper interface {
+	skipped(ctx *PipelineContext) bool
+}
+
+// PhaseFactory 创建新的 PhaseUnit 实例，用于无法仅凭类型创建的单元（如适配器）
+type PhaseFactory func() PhaseUnit
+
 type UnitRepository struct {
-	mu       sync.RWMutex
-	Mappings map[string]reflect.Type // 存储所有单元的映射关系
+	mu        sync.RWMutex
+	Mappings  map[string]reflect.Type // 存储所有单元的映射关系
+	Factories map[string]PhaseFactory // 通过工厂注册的单元，与 Mappings 中的同名注册互相覆盖
 }
 
 func (r *UnitRepository) RegisterUnit(name string, unit any) {
@@ -120,6 +140,33 @@ func (r *UnitRepository) RegisterUnit(name string, unit any) {
 		r.Mappings = make(map[string]reflect.Type)
 	}
 	r.Mappings[name] = reflect.TypeOf(unit).Elem()
+	delete(r.Factories, name)
+}
+
+func (r *UnitRepository) RegisterFactory(name string, factory PhaseFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Factories == nil {
+		r.Factories = make(map[string]PhaseFactory)
+	}
+	r.Factories[name] = factory
+	delete(r.Mappings, name)
+}
+
+// NewUnit 创建已注册单元的新实例
+func (r *UnitRepository) NewUnit(name string) (PhaseUnit, bool) {
+	r.mu.RLock()
+	factory, hasFactory := r.Factories[name]
+	unitType, hasType := r.Mappings[name]
+	r.mu.RUnlock()
+	switch {
+	case hasFactory:
+		return factory(), true
+	case hasType:
+		unit, ok := reflect.New(unitType).Interface().(PhaseUnit)
+		return unit, ok
+	}
+	return nil, false
 }
 
 func (r *UnitRepository) ParsePhaseUnitsFromMap(rawList []map[string]any) ([]PhaseUnit, error) {
@@ -145,19 +192,17 @@ func (r *UnitRepository) ParsePhaseUnits(jsonData []byte, typeField string) ([]P
 			return nil, fmt.Errorf("缺少 {%s} 字段", typeField)
 		}
 
-		unitType, ok := r.Mappings[typeVal]
+		unit, ok := r.NewUnit(typeVal)
 		if !ok {
 			return nil, fmt.Errorf("未知的类型: %s", typeVal)
 		}
-
-		unitPtr := reflect.New(unitType).Interface()
 		unitJSON, _ := json.Marshal(raw)
 
-		if err := json.Unmarshal(unitJSON, unitPtr); err != nil {
+		if err := json.Unmarshal(unitJSON, unit); err != nil {
 			return nil, err
 		}
 
-		units = append(units, unitPtr.(PhaseUnit))
+		units = append(units, unit)
 	}
 	return units, nil
 }
@@ -188,10 +233,21 @@ type Operator struct {
 
 // Pipeline 由多个 PhaseUnit 组成，并持有执行上下文
 type Pipeline struct {
-	Units       []PhaseUnit      `json:"units,omitempty"`
-	Context     *PipelineContext `json:"-"`
+	Units   []PhaseUnit      `json:"units,omitempty"`
+	Context *PipelineContext `json:"-"`
+	// Interrupted 最近一次运行是否被中断，运行结束时写入
+	//
+	// Deprecated: 使用 IsInterrupted 与 Interrupt；该字段只在运行结束后有效，设置它不会中断运行
 	Interrupted bool
 	LastOutput  Output
+	RunID       string              `json:"run_id,omitempty"` // 为空时每次 Run 自动生成
+	Events      *fn.EventBus[Event] `json:"-"`                // 设置后事件发布到以 RunID 命名的 topic
+	Listeners   []Listener          `json:"-"`
+	// EventRetention 运行结束后 topic 的保留时长，0 使用 DefaultEventRetention，小于 0 不自动删除
+	EventRetention time.Duration          `json:"-"`
+	interrupt      atomic.Pointer[string] // 非空表示已请求中断，值为原因
+	mu             sync.Mutex             // 保护 cancel 与 PipeStatus
+	cancel         context.CancelCauseFunc
 }
 
 func PrepareUnits(units []PhaseUnit) []PhaseUnit {
@@ -213,7 +269,83 @@ func NewPipeline(units []PhaseUnit) *Pipeline {
 }
 
 func (p *Pipeline) Interrupt() {
-	p.Interrupted = true
+	p.InterruptWithReason("")
+}
+
+// InterruptWithReason 可在任意 goroutine 中调用：取消正在执行的单元的 Context，且不再执行后续单元。
+// 多次调用时以第一次的原因为准；中断状态保留到下一次 RunContext 开始，同一个 Pipeline 可以再次运行
+func (p *Pipeline) InterruptWithReason(reason string) {
+	if !p.interrupt.CompareAndSwap(nil, &reason) {
+		return
+	}
+	p.mu.Lock()
+	cancel := p.cancel
+	p.mu.Unlock()
+	if cancel != nil {
+		cancel(p.interruptErr())
+	}
+}
+
+func (p *Pipeline) IsInterrupted() bool {
+	return p.interrupt.Load() != nil
+}
+
+func (p *Pipeline) InterruptReason() string {
+	if reason := p.interrupt.Load(); reason != nil {
+		return *reason
+	}
+	return ""
+}
+
+func (p *Pipeline) interruptErr() error {
+	if reason := p.InterruptReason(); reason != "" {
+		return fmt.Errorf("%w: %s", ErrPipelineInterrupted, reason)
+	}
+	return ErrPipelineInterrupted
+}
+
+// Status 当前 PipeStatus 的副本，可在其他 goroutine 中读取
+func (p *Pipeline) Status() PipeStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.Context.PipeStatus
+}
+
+// OnEvent 注册运行事件监听
+func (p *Pipeline) OnEvent(l Listener) *Pipeline {
+	p.Listeners = append(p.Listeners, l)
+	return p
+}
+
+// report 更新 PipeStatus 并通知 Handler
+func (p *Pipeline) report(step string, status JobStatus) {
+	p.updateStatus(func(s *PipeStatus) {
+		s.Step = step
+		s.Status = status
+	})
+}
+
+func (p *Pipeline) updateStatus(update func(s *PipeStatus)) {
+	p.mu.Lock()
+	update(&p.Context.PipeStatus)
+	status := p.Context.PipeStatus
+	p.mu.Unlock()
+	if p.Context.Handler != nil {
+		p.Context.Handler(p.Context, status)
+	}
+}
+
+// terminalStatus 由 Run 的错误得出最终状态
+func terminalStatus(err error) JobStatus {
+	switch {
+	case err == nil:
+		return TaskCompleted
+	case errors.Is(err, ErrPipelineInterrupted):
+		return TaskInterrupted
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return TaskCancelled
+	}
+	return TaskFailed
 }
 
 func GetInput(unit PhaseUnit, env map[string]any) (*Input, error) {
@@ -257,7 +389,7 @@ func GetInput(unit PhaseUnit, env map[string]any) (*Input, error) {
 					fmt.Println("模型参数合法")
 				}
 				rendered := fn.RenderTemplateStrictly(str, parsed, renderModel, false)
-				slog.Info("渲染后的模板：", rendered)
+				slog.Info("渲染后的模板：", "rendered", rendered)
 				//p.Context.Env[
 				input = &Input{
 					Data:      rendered,
@@ -278,28 +410,108 @@ func GetInput(unit PhaseUnit, env map[string]any) (*Input, error) {
 	return input, nil
 }
 
+// Run 使用 PipelineContext.Context 运行，见 RunContext
 func (p *Pipeline) Run() error {
+	ctx := context.Background()
+	if p.Context != nil && p.Context.Context != nil {
+		ctx = p.Context.Context
+	}
+	return p.RunContext(ctx)
+}
+
+// RunContext 依次执行单元。ctx 取消或调用 Interrupt 后，正在执行的单元通过 PipelineContext.Context 感知，
+// 之后不再执行新的单元；结束时 PipeStatus.Status 为最终状态
+func (p *Pipeline) RunContext(ctx context.Context) (err error) {
+	if p.Context == nil {
+		p.Context = &PipelineContext{Env: make(map[string]any)}
+	}
+	if p.Context.Env == nil {
+		p.Context.Env = make(map[string]any)
+	}
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	p.interrupt.Store(nil)
+	p.mu.Lock()
+	p.cancel = cancel
+	p.mu.Unlock()
+	if p.IsInterrupted() {
+		cancel(p.interruptErr())
+	}
+	parent := p.Context.Context
+	p.Context.Context = runCtx
+	defer func() {
+		p.mu.Lock()
+		p.cancel = nil
+		p.mu.Unlock()
+		p.Context.Context = parent
+	}()
+
 	env := p.Context.Env
 	queue := append([]PhaseUnit{}, p.Units...)
+	runID := p.RunID
+	if runID == "" {
+		runID = fn.Uuid()
+	}
+	events := NewEmitter(p.Events, runID, p.Listeners)
+	events.Emit(Event{Type: EventRunStarted, Status: TaskRunning})
+	p.updateStatus(func(s *PipeStatus) {
+		*s = PipeStatus{Total: int32(len(queue)), Status: TaskRunning}
+	})
+	defer func() {
+		evt := Event{Type: EventRunFinished, Status: terminalStatus(err)}
+		if err != nil {
+			evt.Error = err.Error()
+		}
+		p.updateStatus(func(s *PipeStatus) {
+			s.Status = evt.Status
+			s.Error = evt.Error
+			if evt.Status == TaskInterrupted {
+				s.Reason = p.InterruptReason()
+			}
+		})
+		p.Interrupted = evt.Status == TaskInterrupted
+		events.Emit(evt)
+		events.Expire(p.EventRetention)
+	}()
+	// stopped 运行被取消或中断时返回其原因
+	stopped := func() error {
+		if runCtx.Err() != nil {
+			return context.Cause(runCtx)
+		}
+		return nil
+	}
 
 	for len(queue) > 0 {
-		if p.Interrupted {
-			fmt.Println("中断，停止执行")
-			break
+		if err := stopped(); err != nil {
+			slog.Info("中断，停止执行", "reason", err)
+			return err
 		}
 
 		unit := queue[0]
 		queue = queue[1:]
+		if s, ok := unit.(skipper); ok && s.skipped(p.Context) {
+			events.Emit(Event{Type: EventNodeSkipped, Node: unit.GetID(), Status: TaskSkipped})
+			continue
+		}
 
 		input, err := GetInput(unit, env)
 		if err != nil {
+			events.Emit(Event{Type: EventNodeFailed, Node: unit.GetID(), Status: TaskFailed, Error: err.Error()})
 			return err
 		}
 		slog.Info("执行单元：", "单元id", unit.GetID(), "单元名称", unit.GetUnitName(), "input", input)
+		p.report(unit.GetID(), TaskRunning)
+		events.Emit(Event{Type: EventNodeStarted, Node: unit.GetID(), Attempt: 1, Status: TaskRunning})
 		res, err := unit.Execute(p.Context, input)
 		if err != nil {
+			// 单元因取消而失败时以取消原因结束
+			if stop := stopped(); stop != nil {
+				err = stop
+			}
+			events.Emit(Event{Type: EventNodeFailed, Node: unit.GetID(), Attempt: 1, Status: terminalStatus(err), Error: err.Error()})
 			return err
 		}
+		events.Emit(Event{Type: EventNodeSucceeded, Node: unit.GetID(), Attempt: 1, Status: TaskCompleted})
 		// 写入输出
 		if unit.GetID() != "" && res != nil {
 			env[unit.GetID()] = map[string]any{"output": res.Data}
@@ -311,6 +523,9 @@ func (p *Pipeline) Run() error {
 		// 动态追加下一步
 		next := unit.Next(p.Context, nil)
 		if len(next) > 0 {
+			p.updateStatus(func(s *PipeStatus) {
+				s.Total += int32(len(next))
+			})
 			//插入队首，支持条件跳转
 			queue = append(next, queue...)
 			continue // 跳过当前循环，开始新分支执行
@@ -0,0 +1,112 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ninenhan/go-workflow/fn"
+)
+
+// waitUnit 阻塞直到运行被取消或中断
+type waitUnit struct {
+	BaseUnit
+	started chan struct{}
+}
+
+func (u *waitUnit) GetUnitName() string {
+	return "waitUnit"
+}
+
+func (u *waitUnit) Execute(ctx *PipelineContext, input *Input) (*Output, error) {
+	close(u.started)
+	<-ctx.Context.Done()
+	return nil, context.Cause(ctx.Context)
+}
+
+func newWaitPipeline() (*Pipeline, chan struct{}) {
+	started := make(chan struct{})
+	return NewPipeline([]PhaseUnit{&waitUnit{started: started}}), started
+}
+
+func TestPipelineInterruptWithReason(t *testing.T) {
+	p, started := newWaitPipeline()
+	errCh := make(chan error, 1)
+	go func() { errCh <- p.RunContext(context.Background()) }()
+	<-started
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.InterruptWithReason("user stop")
+			_ = p.Status()
+		}()
+	}
+	wg.Wait()
+	err := <-errCh
+	if !errors.Is(err, ErrPipelineInterrupted) {
+		t.Fatalf("want ErrPipelineInterrupted, got %v", err)
+	}
+	if s := p.Status(); s.Status != TaskInterrupted || s.Reason != "user stop" {
+		t.Fatalf("unexpected status %+v", s)
+	}
+}
+
+func TestPipelineContextCancel(t *testing.T) {
+	p, started := newWaitPipeline()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	err := p.RunContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("want context.Canceled, got %v", err)
+	}
+	if s := p.Status(); s.Status != TaskCancelled || s.Error == "" {
+		t.Fatalf("unexpected status %+v", s)
+	}
+}
+
+func TestPipelineEventTopicExpires(t *testing.T) {
+	bus := fn.NewBus[Event]()
+	p := NewPipeline(nil)
+	p.Events = bus
+	p.RunID = "pipe-1"
+	p.EventRetention = 10 * time.Millisecond
+	sub := bus.GetOrCreateTopic("pipe-1", DefaultEventCache).Subscribe(16)
+	if err := p.RunContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var last EventType
+	for evt := range sub.Ch {
+		last = evt.Data.Type
+	}
+	if last != EventRunFinished || bus.GetTopic("pipe-1") != nil {
+		t.Fatalf("topic not expired after run_finished (last event %s)", last)
+	}
+}
+
+func TestInterruptedPipelineRunsAgain(t *testing.T) {
+	p, started := newWaitPipeline()
+	go func() {
+		<-started
+		p.Interrupt()
+	}()
+	if err := p.RunContext(context.Background()); !errors.Is(err, ErrPipelineInterrupted) {
+		t.Fatalf("want ErrPipelineInterrupted, got %v", err)
+	}
+	if !p.Interrupted || !p.IsInterrupted() {
+		t.Fatal("interrupt not reported after the run")
+	}
+	p.Units = nil
+	if err := p.RunContext(context.Background()); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if p.Interrupted || p.IsInterrupted() || p.Status().Status != TaskCompleted {
+		t.Fatalf("interrupt leaked into the second run: %+v", p.Status())
+	}
+}
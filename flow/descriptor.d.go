@@ -0,0 +1,86 @@
+package flow
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/ninenhan/go-workflow/fn"
+)
+
+// 单元种类
+const (
+	KindNode  = "node"  // core.ExecutableUnit，用于 Graph
+	KindPhase = "phase" // PhaseUnit，用于 Pipeline
+)
+
+// UnitDescriptor 单元的描述信息，供编辑器生成组件面板和参数表单
+type UnitDescriptor struct {
+	Name        string `json:"name"`
+	Version     string `json:"version,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+	Category    string `json:"category,omitempty"`
+	Description string `json:"description,omitempty"`
+	Kind        string `json:"kind"`
+	InputType   string `json:"input_type,omitempty"`  // plaintext, json, json_array...
+	OutputType  string `json:"output_type,omitempty"` // 同 InputType
+	// Params 参数的 JSON Schema，由结构体字段的 json/desc/default/enum/required 标签生成
+	Params map[string]any `json:"params"`
+}
+
+// Describer 单元可选实现，补充分类、说明、输入输出类型等信息
+type Describer interface {
+	Describe(d *UnitDescriptor)
+}
+
+// NewUnitDescriptor 由单元实例生成描述，skip 为不属于参数的嵌入元信息类型
+func NewUnitDescriptor(name, kind string, unit any, skip ...reflect.Type) UnitDescriptor {
+	d := UnitDescriptor{Name: name, Kind: kind, Params: map[string]any{"type": "object"}}
+	if unit != nil {
+		d.Params = fn.JSONSchema(reflect.TypeOf(unit), skip...)
+	}
+	if describer, ok := unit.(Describer); ok {
+		describer.Describe(&d)
+	}
+	if d.DisplayName == "" {
+		d.DisplayName = name
+	}
+	return d
+}
+
+var baseUnitType = reflect.TypeOf(BaseUnit{})
+
+// Describe 生成已注册 PhaseUnit 的描述
+func (r *UnitRepository) Describe(name string) (UnitDescriptor, bool) {
+	unit, ok := r.NewUnit(name)
+	if !ok {
+		return UnitDescriptor{}, false
+	}
+	return NewUnitDescriptor(name, KindPhase, unit, baseUnitType), true
+}
+
+// Names 按名称排序返回所有已注册的单元名
+func (r *UnitRepository) Names() []string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.Mappings)+len(r.Factories))
+	for name := range r.Mappings {
+		names = append(names, name)
+	}
+	for name := range r.Factories {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// ListUnits 按名称排序返回所有已注册 PhaseUnit 的描述
+func (r *UnitRepository) ListUnits() []UnitDescriptor {
+	names := r.Names()
+	list := make([]UnitDescriptor, 0, len(names))
+	for _, name := range names {
+		if d, ok := r.Describe(name); ok {
+			list = append(list, d)
+		}
+	}
+	return list
+}
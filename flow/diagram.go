@@ -0,0 +1,259 @@
+package flow
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type DiagramShape string
+
+const (
+	ShapeBox      DiagramShape = "box"
+	ShapeDecision DiagramShape = "decision" // 分支、条件
+	ShapeLoop     DiagramShape = "loop"
+	ShapeSubgraph DiagramShape = "subgraph"
+	ShapeMap      DiagramShape = "map" // 逐项执行
+)
+
+// DiagramNode 图中的节点，Status 为空表示没有运行信息
+type DiagramNode struct {
+	ID       string
+	Label    string
+	Shape    DiagramShape
+	Status   JobStatus
+	Duration time.Duration
+}
+
+type DiagramEdge struct {
+	From   string
+	To     string
+	Label  string
+	Dashed bool // 回边、循环等非主路径
+}
+
+// Diagram 与具体引擎无关的流程图，可渲染为 Mermaid 或 Graphviz DOT
+type Diagram struct {
+	Nodes []DiagramNode
+	Edges []DiagramEdge
+}
+
+// statusColors 运行状态对应的填充色
+var statusColors = map[JobStatus]string{
+	TaskCompleted:   "#c8e6c9",
+	TaskFailed:      "#ffcdd2",
+	TaskCancelled:   "#e0e0e0",
+	TaskSkipped:     "#f5f5f5",
+	TaskInterrupted: "#fff9c4",
+	TaskRunning:     "#bbdefb",
+	TaskPending:     "#ffffff",
+}
+
+func (n DiagramNode) label() string {
+	label := n.Label
+	if n.Status != "" {
+		label += "\n" + string(n.Status)
+		if d := n.Duration; d >= time.Millisecond {
+			label += " " + d.Round(time.Millisecond).String()
+		} else if d > 0 {
+			label += " " + d.String()
+		}
+	}
+	return label
+}
+
+// ids 节点在输出中的标识，避免节点名中的特殊字符破坏语法
+func (d *Diagram) ids() map[string]string {
+	ids := make(map[string]string, len(d.Nodes))
+	for i, n := range d.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+	}
+	return ids
+}
+
+func mermaidText(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	return strings.ReplaceAll(s, "\n", "<br/>")
+}
+
+// Mermaid 渲染为 Mermaid flowchart
+func (d *Diagram) Mermaid() string {
+	ids := d.ids()
+	var sb strings.Builder
+	sb.WriteString("flowchart TD\n")
+	for _, n := range d.Nodes {
+		text := `"` + mermaidText(n.label()) + `"`
+		switch n.Shape {
+		case ShapeDecision:
+			text = "{" + text + "}"
+		case ShapeLoop:
+			text = "{{" + text + "}}"
+		case ShapeSubgraph:
+			text = "[[" + text + "]]"
+		case ShapeMap:
+			text = "[/" + text + "/]"
+		default:
+			text = "[" + text + "]"
+		}
+		fmt.Fprintf(&sb, "    %s%s\n", ids[n.ID], text)
+	}
+	for _, e := range d.Edges {
+		arrow := "-->"
+		if e.Dashed {
+			arrow = "-.->"
+		}
+		if e.Label != "" {
+			fmt.Fprintf(&sb, "    %s %s|\"%s\"| %s\n", ids[e.From], arrow, mermaidText(e.Label), ids[e.To])
+		} else {
+			fmt.Fprintf(&sb, "    %s %s %s\n", ids[e.From], arrow, ids[e.To])
+		}
+	}
+	used := map[JobStatus]bool{}
+	for _, n := range d.Nodes {
+		if color, ok := statusColors[n.Status]; ok {
+			if !used[n.Status] {
+				used[n.Status] = true
+				fmt.Fprintf(&sb, "    classDef %s fill:%s\n", strings.ToLower(string(n.Status)), color)
+			}
+			fmt.Fprintf(&sb, "    class %s %s\n", ids[n.ID], strings.ToLower(string(n.Status)))
+		}
+	}
+	return sb.String()
+}
+
+func dotText(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return strings.ReplaceAll(s, "\n", `\n`)
+}
+
+// DOT 渲染为 Graphviz DOT
+func (d *Diagram) DOT() string {
+	ids := d.ids()
+	var sb strings.Builder
+	sb.WriteString("digraph workflow {\n    rankdir=TB;\n    node [shape=box, style=rounded];\n")
+	for _, n := range d.Nodes {
+		attrs := []string{fmt.Sprintf(`label="%s"`, dotText(n.label()))}
+		switch n.Shape {
+		case ShapeDecision:
+			attrs = append(attrs, "shape=diamond")
+		case ShapeLoop:
+			attrs = append(attrs, "shape=hexagon")
+		case ShapeSubgraph:
+			attrs = append(attrs, "shape=box", "peripheries=2")
+		case ShapeMap:
+			attrs = append(attrs, "shape=parallelogram")
+		}
+		if color, ok := statusColors[n.Status]; ok {
+			attrs = append(attrs, `style="rounded,filled"`, fmt.Sprintf(`fillcolor="%s"`, color))
+		}
+		fmt.Fprintf(&sb, "    %s [%s];\n", ids[n.ID], strings.Join(attrs, ", "))
+	}
+	for _, e := range d.Edges {
+		var attrs []string
+		if e.Label != "" {
+			attrs = append(attrs, fmt.Sprintf(`label="%s"`, dotText(e.Label)))
+		}
+		if e.Dashed {
+			attrs = append(attrs, "style=dashed")
+		}
+		if len(attrs) > 0 {
+			fmt.Fprintf(&sb, "    %s -> %s [%s];\n", ids[e.From], ids[e.To], strings.Join(attrs, ", "))
+		} else {
+			fmt.Fprintf(&sb, "    %s -> %s;\n", ids[e.From], ids[e.To])
+		}
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// Diagram 将 Pipeline 展开为流程图，IfUnit/WhileUnit 的嵌套单元按分支展开
+func (p *Pipeline) Diagram() *Diagram {
+	b := &pipelineDiagram{d: &Diagram{}}
+	b.sequence(p.Units, nil)
+	return b.d
+}
+
+func (p *Pipeline) Mermaid() string {
+	return p.Diagram().Mermaid()
+}
+
+func (p *Pipeline) DOT() string {
+	return p.Diagram().DOT()
+}
+
+// exit 尚未连接到下一个单元的出口
+type exit struct {
+	from  string
+	label string
+}
+
+type pipelineDiagram struct {
+	d    *Diagram
+	next int
+}
+
+func (b *pipelineDiagram) add(unit PhaseUnit, shape DiagramShape) string {
+	id := fmt.Sprintf("u%d", b.next)
+	b.next++
+	label := unit.GetUnitName()
+	if unit.GetID() != "" {
+		label = fmt.Sprintf("%s\n%s", label, unit.GetID())
+	}
+	b.d.Nodes = append(b.d.Nodes, DiagramNode{ID: id, Label: label, Shape: shape})
+	return id
+}
+
+func (b *pipelineDiagram) connect(exits []exit, to string) {
+	for _, e := range exits {
+		b.d.Edges = append(b.d.Edges, DiagramEdge{From: e.from, To: to, Label: e.label})
+	}
+}
+
+// sequence 依次连接 units，返回最后的出口
+func (b *pipelineDiagram) sequence(units []PhaseUnit, exits []exit) []exit {
+	for _, unit := range units {
+		switch u := unit.(type) {
+		case *IfUnit:
+			id := b.add(u, ShapeDecision)
+			b.connect(exits, id)
+			exits = b.branch(id, conditionLabel(u.IfCondition, "if"), u.IfUnits, nil)
+			for i, cond := range u.ElseIfConditions {
+				var body []PhaseUnit
+				if i < len(u.ElseIfUnits) {
+					body = u.ElseIfUnits[i]
+				}
+				exits = b.branch(id, conditionLabel(cond, "else if"), body, exits)
+			}
+			exits = b.branch(id, "else", u.ElseUnits, exits)
+		case *WhileUnit:
+			id := b.add(u, ShapeLoop)
+			b.connect(exits, id)
+			body := b.sequence(u.Units, []exit{{from: id, label: conditionLabel(u.Condition, "while")}})
+			for _, e := range body {
+				b.d.Edges = append(b.d.Edges, DiagramEdge{From: e.from, To: id, Label: e.label, Dashed: true})
+			}
+			exits = []exit{{from: id, label: "exit"}}
+		default:
+			id := b.add(u, ShapeBox)
+			b.connect(exits, id)
+			exits = []exit{{from: id}}
+		}
+	}
+	return exits
+}
+
+func conditionLabel(c Condition, fallback string) string {
+	if s := c.String(); s != "" {
+		return s
+	}
+	return fallback
+}
+
+// branch 展开一个分支，空分支直接以条件为标签连到下一个单元
+func (b *pipelineDiagram) branch(from, label string, units []PhaseUnit, exits []exit) []exit {
+	if len(units) == 0 {
+		return append(exits, exit{from: from, label: label})
+	}
+	return append(exits, b.sequence(units, []exit{{from: from, label: label}})...)
+}
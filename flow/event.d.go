@@ -0,0 +1,115 @@
+package flow
+
+import (
+	"github.com/ninenhan/go-workflow/fn"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventRunStarted      EventType = "run_started"
+	EventNodeScheduled   EventType = "node_scheduled"
+	EventNodeStarted     EventType = "node_started"
+	EventNodeSucceeded   EventType = "node_succeeded"
+	EventNodeFailed      EventType = "node_failed"
+	EventNodeRetried     EventType = "node_retried"
+	EventNodeSkipped     EventType = "node_skipped"
+	EventNodeInterrupted EventType = "node_interrupted"
+	EventStreamChunk     EventType = "stream_chunk"
+	EventRunFinished     EventType = "run_finished"
+)
+
+// DefaultEventCache 每个运行的 topic 保留的历史事件数
+const DefaultEventCache = 1024
+
+// DefaultEventRetention run_finished 之后 topic 保留的时长，供订阅方回放历史
+const DefaultEventRetention = 5 * time.Minute
+
+// Event 运行生命周期事件，Graph 与 Pipeline 共用
+type Event struct {
+	Type    EventType `json:"type"`
+	RunID   string    `json:"run_id"`
+	Node    string    `json:"node,omitempty"`
+	Attempt int       `json:"attempt,omitempty"`
+	Status  JobStatus `json:"status,omitempty"`
+	Data    any       `json:"data,omitempty"` // 流式分片等附加数据
+	Error   string    `json:"error,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// Listener 同步接收事件；同一次运行的事件按顺序逐个投递，不会并发调用
+type Listener func(Event)
+
+// Emitter 将一次运行的事件发布到 EventBus 上以运行 ID 命名的 topic，并调用各监听函数。
+// 订阅方可先 GetOrCreateTopic(runID) 再订阅，或在运行后通过 Topic.Snapshot 回放历史；
+// 运行结束后由 Expire 删除 topic，订阅方的 channel 随之关闭。
+type Emitter struct {
+	mu        sync.Mutex
+	runID     string
+	bus       *fn.EventBus[Event]
+	topic     *fn.Topic[Event]
+	listeners []Listener
+	finished  bool // 已发布 run_finished
+}
+
+// NewEmitter bus 与 listeners 都为空时返回 nil，nil 的 Emitter 忽略所有事件
+func NewEmitter(bus *fn.EventBus[Event], runID string, listeners []Listener) *Emitter {
+	if bus == nil && len(listeners) == 0 {
+		return nil
+	}
+	e := &Emitter{runID: runID, bus: bus, listeners: listeners}
+	if bus != nil {
+		e.topic = bus.GetOrCreateTopic(runID, DefaultEventCache)
+	}
+	return e
+}
+
+// Emit 补全运行 ID 与时间后投递事件；订阅方缓冲已满时丢弃并记录日志，不阻塞运行。
+// run_finished 之后的事件（如超时或取消后被放弃的节点仍在执行）直接丢弃
+func (e *Emitter) Emit(evt Event) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.finished {
+		return
+	}
+	e.finished = evt.Type == EventRunFinished
+	evt.RunID = e.runID
+	if evt.Time.IsZero() {
+		evt.Time = time.Now()
+	}
+	if e.topic != nil {
+		if err := e.topic.Publish(evt); err != nil {
+			slog.Debug("发布事件失败", "run_id", e.runID, "type", evt.Type, "err", err)
+		}
+	}
+	for _, l := range e.listeners {
+		l(evt)
+	}
+}
+
+// Expire 在 retention 之后删除本次运行的 topic：0 使用 DefaultEventRetention，小于 0 不删除，由调用方 RemoveTopic。
+// 到期时同一运行 ID 又开始了新的运行（如 Resume）则保留，由新的运行负责删除。
+func (e *Emitter) Expire(retention time.Duration) {
+	if e == nil || e.topic == nil || retention < 0 {
+		return
+	}
+	if retention == 0 {
+		retention = DefaultEventRetention
+	}
+	time.AfterFunc(retention, func() {
+		if e.bus.GetTopic(e.runID) != e.topic {
+			return
+		}
+		history := e.topic.Snapshot()
+		if len(history) > 0 && history[len(history)-1].Data.Type != EventRunFinished {
+			return
+		}
+		e.bus.RemoveTopic(e.runID)
+	})
+}
@@ -25,6 +25,15 @@ func RegisterUnit(name string, unit any) {
 	getUnitRepo().RegisterUnit(name, unit)
 }
 
+func RegisterUnitFactory(name string, factory PhaseFactory) {
+	getUnitRepo().RegisterFactory(name, factory)
+}
+
+// NewUnit 创建已注册单元的新实例
+func NewUnit(name string) (PhaseUnit, bool) {
+	return getUnitRepo().NewUnit(name)
+}
+
 func ParsePhaseUnits(jsonData []byte, typeField string) ([]PhaseUnit, error) {
 	return getUnitRepo().ParsePhaseUnits(jsonData, typeField)
 }
@@ -32,3 +41,17 @@ func ParsePhaseUnits(jsonData []byte, typeField string) ([]PhaseUnit, error) {
 func ParsePhaseUnitsFromMap(rawList []map[string]any) ([]PhaseUnit, error) {
 	return getUnitRepo().ParsePhaseUnitsFromMap(rawList)
 }
+
+// ListUnits 所有已注册 PhaseUnit 的描述
+func ListUnits() []UnitDescriptor {
+	return getUnitRepo().ListUnits()
+}
+
+func DescribeUnit(name string) (UnitDescriptor, bool) {
+	return getUnitRepo().Describe(name)
+}
+
+// DefaultUnitRepository 包级函数使用的全局仓库
+func DefaultUnitRepository() *UnitRepository {
+	return getUnitRepo()
+}
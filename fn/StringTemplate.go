@@ -184,6 +184,29 @@ func evalExpr(expr string, model map[string]any) bool {
 	return ok && b
 }
 
+// CompileExpr 校验 govaluate 表达式能否解析
+func CompileExpr(expr string) error {
+	_, err := govaluate.NewEvaluableExpressionWithFunctions(expr, evalFunctions)
+	return err
+}
+
+// EvalBool 计算 govaluate 布尔表达式，结果不是 bool 时返回错误
+func EvalBool(expr string, model map[string]any) (bool, error) {
+	e, err := govaluate.NewEvaluableExpressionWithFunctions(expr, evalFunctions)
+	if err != nil {
+		return false, err
+	}
+	res, err := e.Evaluate(model)
+	if err != nil {
+		return false, err
+	}
+	b, ok := res.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression %q returned %T, not bool", expr, res)
+	}
+	return b, nil
+}
+
 func RenderControlNodes(nodes []Node, model map[string]any) string {
 	var sb strings.Builder
 	for _, n := range nodes {
@@ -282,7 +305,7 @@ func CheckModelValid(model map[string]any) error {
 	return nil
 }
 
-var pathSegmentRegex = regexp.MustCompile(`([\p{Han}\\$\w]+)($begin:math:display$\\d+$end:math:display$)*`)
+var pathSegmentRegex = regexp.MustCompile(`([\p{Han}$\w]+)(\[\d+\])*`)
 
 // ParsePathExpr 将路径字符串（如 用户.好友[0].昵称）解析为 ["用户", "好友", 0, "昵称"]
 func ParsePathExpr(expr string) []any {
@@ -296,7 +319,7 @@ func ParsePathExpr(expr string) []any {
 		}
 		result = append(result, matches[1]) // 字段名
 		// 查找所有索引
-		indexMatches := regexp.MustCompile(`$begin:math:display$(\\d+)$end:math:display$`).FindAllStringSubmatch(seg, -1)
+		indexMatches := regexp.MustCompile(`\[(\d+)\]`).FindAllStringSubmatch(seg, -1)
 		for _, im := range indexMatches {
 			idx, _ := strconv.Atoi(im[1])
 			result = append(result, idx)
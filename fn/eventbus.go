@@ -52,30 +52,44 @@ func (t *Topic[T]) Publish(data T) error {
 	if len(t.history) > t.maxCache {
 		t.history = t.history[1:]
 	}
+	var err error
 	for _, sub := range t.subs {
 		select {
 		case sub.Ch <- evt:
+			sub.Cursor = evt.ID
 		default:
-			// Drop on full buffer
+			// Drop on full buffer, keep delivering to the others
 			atomic.AddInt64(&t.dropped, 1)
-			return errors.New("subscriber buffer full")
+			err = errors.New("subscriber buffer full")
 		}
 	}
-	return nil
+	return err
 }
 
-// Subscribe adds a new subscriber with buffer size.
+// Subscribe adds a new subscriber with buffer size, replaying the whole history.
 func (t *Topic[T]) Subscribe(buffer int) *Subscriber[T] {
+	return t.SubscribeFrom(-1, buffer)
+}
+
+// SubscribeFrom adds a new subscriber that first receives the cached events
+// with ID greater than cursor. The channel grows to hold the replayed events.
+func (t *Topic[T]) SubscribeFrom(cursor int64, buffer int) *Subscriber[T] {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	var replay []Event[T]
+	for _, evt := range t.history {
+		if evt.ID > cursor {
+			replay = append(replay, evt)
+		}
+	}
 	t.subIDGen++
 	sub := &Subscriber[T]{
-		ID: t.subIDGen,
-		Ch: make(chan Event[T], buffer),
+		ID:     t.subIDGen,
+		Ch:     make(chan Event[T], len(replay)+buffer),
+		Cursor: cursor,
 	}
-	// Replay history to new subscriber
-	for _, evt := range t.history {
+	for _, evt := range replay {
 		sub.Ch <- evt
 		sub.Cursor = evt.ID
 	}
@@ -132,9 +146,40 @@ func (b *EventBus[T]) CreateTopic(name string, maxCache int) (*Topic[T], error)
 }
 
 func (b *EventBus[T]) GetTopic(name string) *Topic[T] {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
 	return b.topics[name]
 }
 
+// GetOrCreateTopic returns the named topic, creating it if needed.
+func (b *EventBus[T]) GetOrCreateTopic(name string, maxCache int) *Topic[T] {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if t, ok := b.topics[name]; ok {
+		return t
+	}
+	t := NewTopic[T](name, maxCache)
+	b.topics[name] = t
+	return t
+}
+
+// RemoveTopic drops a topic and closes its subscribers.
+func (b *EventBus[T]) RemoveTopic(name string) {
+	b.mu.Lock()
+	t, ok := b.topics[name]
+	delete(b.topics, name)
+	b.mu.Unlock()
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id, sub := range t.subs {
+		close(sub.Ch)
+		delete(t.subs, id)
+	}
+}
+
 // Publish sends data to a named topic.
 func (b *EventBus[T]) Publish(topic string, data T) error {
 	b.mu.RLock()
@@ -0,0 +1,243 @@
+package fn
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// JSONSchema 由结构体字段与标签生成 JSON Schema（draft 2020-12 的子集）。
+// 字段名取 json 标签；desc、default、enum（逗号分隔）标签生成对应关键字，required:"true" 标记必填。
+// skip 中的嵌入类型不展开，用于跳过单元的元信息字段
+func JSONSchema(t reflect.Type, skip ...reflect.Type) map[string]any {
+	return schemaOf(t, skip, map[reflect.Type]bool{})
+}
+
+func schemaOf(t reflect.Type, skip []reflect.Type, visiting map[reflect.Type]bool) map[string]any {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == durationType:
+		return map[string]any{"type": "integer", "description": "nanoseconds"}
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() != reflect.Struct && reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return map[string]any{"type": "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem(), skip, visiting)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(t.Elem(), skip, visiting)}
+	case reflect.Struct:
+		// 递归类型只展开一层
+		if visiting[t] {
+			return map[string]any{"type": "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		properties := map[string]any{}
+		var required []string
+		structFields(t, skip, visiting, properties, &required)
+		schema := map[string]any{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	// interface、func 等无法推断
+	return map[string]any{}
+}
+
+func structFields(t reflect.Type, skip []reflect.Type, visiting map[reflect.Type]bool, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if containsType(skip, ft) {
+				continue
+			}
+			if ft.Kind() == reflect.Struct {
+				structFields(ft, skip, visiting, properties, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		schema := schemaOf(f.Type, skip, visiting)
+		if desc := f.Tag.Get("desc"); desc != "" {
+			schema["description"] = desc
+		}
+		if def, ok := f.Tag.Lookup("default"); ok {
+			schema["default"] = tagValue(def, schema["type"])
+		}
+		if enum := f.Tag.Get("enum"); enum != "" {
+			var values []any
+			for _, v := range strings.Split(enum, ",") {
+				values = append(values, tagValue(strings.TrimSpace(v), schema["type"]))
+			}
+			schema["enum"] = values
+		}
+		if f.Tag.Get("required") == "true" {
+			*required = append(*required, name)
+		}
+		properties[name] = schema
+	}
+}
+
+func containsType(types []reflect.Type, t reflect.Type) bool {
+	for _, s := range types {
+		if s == t {
+			return true
+		}
+	}
+	return false
+}
+
+// tagValue 按字段类型转换标签中的字面值，无法转换时保留字符串
+func tagValue(s string, typ any) any {
+	switch typ {
+	case "integer":
+		if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return v
+		}
+	case "number":
+		if v, err := strconv.ParseFloat(s, 64); err == nil {
+			return v
+		}
+	case "boolean":
+		if v, err := strconv.ParseBool(s); err == nil {
+			return v
+		}
+	}
+	return s
+}
+
+// MissingRequired 返回 v 中标记 required:"true" 但仍为零值的字段（取 json 名），v 为结构体或其指针
+func MissingRequired(v any) []string {
+	return missingRequired(reflect.ValueOf(v))
+}
+
+func missingRequired(rv reflect.Value) []string {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	var missing []string
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			missing = append(missing, missingRequired(rv.Field(i))...)
+			continue
+		}
+		if f.Tag.Get("required") != "true" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if rv.Field(i).IsZero() {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// ApplyDefaults 将 default 标签的值写入 v 中仍为零值的字段，v 为结构体指针。
+// string 字段直接取标签文本，其余与参数一样按 JSON 解码（time.Duration 为纳秒）
+func ApplyDefaults(v any) error {
+	return applyDefaults(reflect.ValueOf(v))
+}
+
+func applyDefaults(rv reflect.Value) error {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct || !rv.CanAddr() {
+		return nil
+	}
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			if err := applyDefaults(rv.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		def, ok := f.Tag.Lookup("default")
+		if !ok || !f.IsExported() || !rv.Field(i).IsZero() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if err := setTagValue(rv.Field(i), def); err != nil {
+			return fmt.Errorf("invalid default for %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func setTagValue(field reflect.Value, s string) error {
+	if field.Kind() == reflect.String {
+		field.SetString(s)
+		return nil
+	}
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	return json.Unmarshal([]byte(s), field.Addr().Interface())
+}
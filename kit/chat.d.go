@@ -62,3 +62,19 @@ func (c *ChatGPTStreamResponse) GetResponse() string {
 	}
 	return strings.Join(results, "")
 }
+
+// AggregateStream 合并流式分片：全部为 ChatGPTStreamResponse 时拼接增量内容，否则原样返回
+func AggregateStream(chunks []any) any {
+	var sb strings.Builder
+	for _, chunk := range chunks {
+		delta, ok := chunk.(ChatGPTStreamResponse)
+		if !ok {
+			return chunks
+		}
+		sb.WriteString(delta.GetResponse())
+	}
+	if len(chunks) == 0 {
+		return chunks
+	}
+	return sb.String()
+}
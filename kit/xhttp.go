@@ -91,13 +91,30 @@ func HandleNonStreamResponseUnTyped(ctx context.Context, resp *http.Response, is
 
 // HandlerHttpWithChannel HTTP 请求处理函数
 func HandlerHttpWithChannel(xRequest XRequest, isPreCooked bool, ch chan<- any) error {
+	return HandlerHttpWithChannelContext(context.Background(), xRequest, isPreCooked, ch)
+}
+
+// HandlerHttpWithChannelContext HTTP 请求处理函数，ctx 取消时中断请求与流读取；无论成功与否 ch 都会被关闭
+func HandlerHttpWithChannelContext(parent context.Context, xRequest XRequest, isPreCooked bool, ch chan<- any) error {
+	return HandlerHttpWithChannelMode(parent, xRequest, isPreCooked, ch, nil)
+}
+
+// HandlerHttpWithChannelMode 同 HandlerHttpWithChannelContext，收到响应后、写入 ch 之前先以 onResponse 告知是否为 text/event-stream
+func HandlerHttpWithChannelMode(parent context.Context, xRequest XRequest, isPreCooked bool, ch chan<- any, onResponse func(stream bool)) error {
+	handled := false
+	defer func() {
+		// 请求阶段失败时，响应处理函数不会被调用，这里负责关闭 ch
+		if !handled {
+			close(ch)
+		}
+	}()
 	// 序列化请求体
 	body, err := json.Marshal(xRequest.Body)
 	if err != nil {
 		return fmt.Errorf("序列化请求体失败: %v", err)
 	}
 	// 创建 HTTP 请求
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, xRequest.Url, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(parent, http.MethodPost, xRequest.Url, bytes.NewBuffer(body))
 	if err != nil {
 		return fmt.Errorf("创建请求失败: %v", err)
 	}
@@ -128,10 +145,15 @@ func HandlerHttpWithChannel(xRequest XRequest, isPreCooked bool, ch chan<- any)
 	}
 	// 读取 Content-Type 确定响应类型
 	contentType := resp.Header.Get("Content-Type")
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	ctx, cancel := context.WithTimeout(parent, 3*time.Minute)
 	defer cancel()
+	handled = true
+	stream := strings.HasPrefix(contentType, "text/event-stream")
+	if onResponse != nil {
+		onResponse(stream)
+	}
 	// 处理 Stream 和非 Stream 两种模式
-	if strings.HasPrefix(contentType, "text/event-stream") {
+	if stream {
 		// Stream 模式：逐行读取数据流
 		return HandleStreamResponseUnTyped(ctx, resp, isPreCooked, ch)
 	} else {
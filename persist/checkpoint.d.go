@@ -0,0 +1,10 @@
+package persist
+
+import "gorm.io/gorm"
+
+type CheckpointOrm struct {
+	gorm.Model
+
+	RunID string `gorm:"type:varchar(255);uniqueIndex"` // 运行 ID，子图为 "父运行 ID/节点名"
+	Data  []byte `gorm:"type:longblob"`                 // 序列化后的检查点
+}
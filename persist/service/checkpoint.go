@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/ninenhan/go-workflow/persist"
+	"github.com/ninenhan/go-workflow/store"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CheckpointStore 基于 gorm 的检查点存储
+type CheckpointStore struct {
+	Orm *gorm.DB
+}
+
+var _ store.CheckpointStore = (*CheckpointStore)(nil)
+
+func NewCheckpointStore(orm *gorm.DB) (*CheckpointStore, error) {
+	if err := orm.AutoMigrate(&persist.CheckpointOrm{}); err != nil {
+		return nil, err
+	}
+	return &CheckpointStore{Orm: orm}, nil
+}
+
+func (s *CheckpointStore) Save(ctx context.Context, runID string, data []byte) error {
+	row := persist.CheckpointOrm{RunID: runID, Data: data}
+	return s.Orm.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "run_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"data", "updated_at"}),
+	}).Create(&row).Error
+}
+
+func (s *CheckpointStore) Load(ctx context.Context, runID string) ([]byte, error) {
+	var row persist.CheckpointOrm
+	err := s.Orm.WithContext(ctx).Where("run_id = ?", runID).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, store.ErrCheckpointNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return row.Data, nil
+}
+
+func (s *CheckpointStore) Delete(ctx context.Context, runID string) error {
+	return s.Orm.WithContext(ctx).Unscoped().Where("run_id = ?", runID).Delete(&persist.CheckpointOrm{}).Error
+}
@@ -5,52 +5,230 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/ninenhan/go-workflow/fn"
+	"maps"
+	"slices"
+	"time"
 )
 
 type NodeJSON struct {
-	UnitID string         `json:"unit_id"`
-	Name   string         `json:"name"`
-	Input  *Input         `json:"input,omitempty"`
-	Params map[string]any `json:"params,omitempty"`
+	ID            string         `json:"id,omitempty"` // 为空时取节点名
+	UnitID        string         `json:"unit_id"`
+	Name          string         `json:"name"`
+	Input         *Input         `json:"input,omitempty"`
+	Params        map[string]any `json:"params,omitempty"`
+	Retry         *RetryPolicy   `json:"retry,omitempty"`
+	TimeoutMs     int64          `json:"timeout_ms,omitempty"` // 节点超时（毫秒）
+	Subgraph      *Subgraph      `json:"subgraph,omitempty"`   // 引用已注册的图，与 unit_id 二选一
+	ForEach       *ForEach       `json:"for_each,omitempty"`   // 对列表逐项执行，与 unit_id 二选一
+	Join          JoinMode       `json:"join,omitempty"`
+	JoinN         int            `json:"join_n,omitempty"`
+	ExportFields  []string       `json:"export_fields,omitempty"`
+	MaxIterations int            `json:"max_iterations,omitempty"`
+	Accumulate    bool           `json:"accumulate,omitempty"`
+	Parallel      bool           `json:"parallel,omitempty"`
+	Layout        map[string]any `json:"layout,omitempty"` // 编辑器的布局信息，原样保存
 }
 
 type GraphJSON struct {
+	Start string              `json:"start,omitempty"` // 为空时取唯一的无入边节点
 	Nodes map[string]NodeJSON `json:"nodes"`
 	Edges map[string][]string `json:"edges"`
+	// Conditions 边上的条件：起点 -> 终点 -> 条件
+	Conditions     map[string]map[string]EdgeCondition `json:"conditions,omitempty"`
+	TimeoutMs      int64                               `json:"timeout_ms,omitempty"` // 整个运行的超时（毫秒）
+	FailFast       bool                                `json:"fail_fast,omitempty"`
+	MaxConcurrency int                                 `json:"max_concurrency,omitempty"`
+	// EventRetentionMs 运行结束后事件 topic 的保留时长（毫秒），0 使用默认值，小于 0 不自动删除
+	EventRetentionMs int64          `json:"event_retention_ms,omitempty"`
+	Layout           map[string]any `json:"layout,omitempty"` // 编辑器的画布信息，原样保存
 }
 
 // BuildGraphFromJSON Graph represents a directed graph structure with nodes and edges.
 func BuildGraphFromJSON(data []byte) (*Graph, error) {
+	return DefaultRegistry.BuildGraphFromJSON(data)
+}
+
+// BuildGraphFromJSON 使用该注册表解析节点的 unit_id
+func (r *Registry) BuildGraphFromJSON(data []byte) (*Graph, error) {
 	var def GraphJSON
 	if err := json.Unmarshal(data, &def); err != nil {
 		return nil, err
 	}
+	return def.BuildWith(r)
+}
 
+// Build 按定义构建并校验图
+func (def *GraphJSON) Build() (*Graph, error) {
+	return def.BuildWith(nil)
+}
+
+// BuildWith 使用指定的注册表构建，r 为空时使用 DefaultRegistry
+func (def *GraphJSON) BuildWith(r *Registry) (*Graph, error) {
 	graph := &Graph{
-		Nodes: map[string]*Node{},
-		Edges: def.Edges,
+		Registry:       r,
+		Nodes:          map[string]*Node{},
+		Edges:          def.Edges,
+		Conditions:     def.Conditions,
+		Timeout:        time.Duration(def.TimeoutMs) * time.Millisecond,
+		FailFast:       def.FailFast,
+		MaxConcurrency: def.MaxConcurrency,
+		EventRetention: time.Duration(def.EventRetentionMs) * time.Millisecond,
+		Layout:         def.Layout,
+	}
+	if graph.Edges == nil {
+		graph.Edges = map[string][]string{}
 	}
 
-	for id, nodeDef := range def.Nodes {
+	for name, nodeDef := range def.Nodes {
+		id := nodeDef.ID
+		if id == "" {
+			id = name
+		}
+		node := &Node{
+			ID:            id,
+			Name:          nodeDef.Name,
+			Input:         nodeDef.Input,
+			UnitID:        nodeDef.UnitID,
+			Retry:         nodeDef.Retry,
+			Timeout:       time.Duration(nodeDef.TimeoutMs) * time.Millisecond,
+			Subgraph:      nodeDef.Subgraph,
+			ForEach:       nodeDef.ForEach,
+			Join:          nodeDef.Join,
+			JoinN:         nodeDef.JoinN,
+			ExportFields:  nodeDef.ExportFields,
+			MaxIterations: nodeDef.MaxIterations,
+			Accumulate:    nodeDef.Accumulate,
+			Parallel:      nodeDef.Parallel,
+			Params:        nodeDef.Params,
+			Layout:        nodeDef.Layout,
+		}
+		// Execute 在编译时由 UnitID 与 Params 创建的实例提供，未注册的 unit 与无效参数由校验统一报告
+		graph.Nodes[name] = node
+	}
 
-		unit, ok := FindUnit(nodeDef.UnitID)
-		if !ok {
-			return nil, fmt.Errorf("unit_id %s not found", nodeDef.UnitID)
+	start, diagnostics := graph.validate(def.Start)
+	if err := diagnostics.Err(); err != nil {
+		return nil, err
+	}
+	graph.start = start
+	return graph, nil
+}
+
+// ToJSON 导出为 GraphJSON。只有由注册单元、已注册子图或 ForEach 定义的节点可以导出，
+// 函数形式的 Execute、Branch、LoopCond、RetryIf 以及依赖 Branch 的 BranchTargets、BranchLabels 无法序列化，
+// 遇到时返回错误。Hooks、Checkpoints、Events、Listeners、Registry 是运行时依赖，不属于图定义，不导出
+func (g *Graph) ToJSON() (*GraphJSON, error) {
+	def := &GraphJSON{
+		Start:            g.start,
+		Nodes:            make(map[string]NodeJSON, len(g.Nodes)),
+		Edges:            make(map[string][]string, len(g.Edges)),
+		TimeoutMs:        g.Timeout.Milliseconds(),
+		FailFast:         g.FailFast,
+		MaxConcurrency:   g.MaxConcurrency,
+		EventRetentionMs: durationMs(g.EventRetention),
+		Layout:           g.Layout,
+	}
+	for name, node := range g.Nodes {
+		if err := node.serializable(name, g.registry()); err != nil {
+			return nil, err
+		}
+		nodeDef := NodeJSON{
+			UnitID:        node.UnitID,
+			Name:          node.Name,
+			Input:         node.Input,
+			Params:        node.Params,
+			Retry:         node.Retry,
+			TimeoutMs:     node.Timeout.Milliseconds(),
+			Subgraph:      node.Subgraph,
+			ForEach:       node.ForEach,
+			Join:          node.Join,
+			JoinN:         node.JoinN,
+			ExportFields:  node.ExportFields,
+			MaxIterations: node.MaxIterations,
+			Accumulate:    node.Accumulate,
+			Parallel:      node.Parallel,
+			Layout:        node.Layout,
 		}
-		executable, ok := unit.(ExecutableUnit)
-		if !ok {
-			return nil, fmt.Errorf("unit %T does not implement ExecutableUnit", unit)
+		if node.ID != name {
+			nodeDef.ID = node.ID
 		}
-		//判断unit是否实现了 ExecutableUnit 接口
-		graph.Nodes[id] = &Node{
-			ID:      id,
-			Name:    nodeDef.Name,
-			Input:   nodeDef.Input,
-			Execute: executable.Execute,
+		def.Nodes[name] = nodeDef
+	}
+	for from, tos := range g.Edges {
+		if len(tos) > 0 {
+			def.Edges[from] = slices.Clone(tos)
+		}
+	}
+	if len(g.Conditions) > 0 {
+		def.Conditions = make(map[string]map[string]EdgeCondition, len(g.Conditions))
+		for from, conds := range g.Conditions {
+			def.Conditions[from] = maps.Clone(conds)
 		}
 	}
+	return def, nil
+}
 
-	return graph, nil
+// durationMs 保留负值（不自动删除），不足 1 毫秒的正值向上取整，避免变为 0（默认值）
+func durationMs(d time.Duration) int64 {
+	switch {
+	case d < 0:
+		return -1
+	case d > 0 && d < time.Millisecond:
+		return 1
+	}
+	return d.Milliseconds()
+}
+
+func (n *Node) serializable(name string, r *Registry) error {
+	switch {
+	case n.Branch != nil:
+		return fmt.Errorf("node %s: branch function cannot be serialized", name)
+	case len(n.BranchTargets) > 0 || len(n.BranchLabels) > 0:
+		return fmt.Errorf("node %s: branch targets and labels belong to a branch function and cannot be serialized", name)
+	case n.LoopCond != nil:
+		return fmt.Errorf("node %s: loop condition cannot be serialized", name)
+	case n.Retry != nil && n.Retry.RetryIf != nil:
+		return fmt.Errorf("node %s: retry condition function cannot be serialized", name)
+	case n.Subgraph != nil:
+		if n.Subgraph.Name == "" {
+			return fmt.Errorf("node %s: subgraph must reference a registered graph by name", name)
+		}
+	case n.ForEach != nil:
+		if n.ForEach.Body != nil {
+			return fmt.Errorf("node %s: foreach body function cannot be serialized", name)
+		}
+		if n.ForEach.Subgraph != nil && n.ForEach.Subgraph.Name == "" {
+			return fmt.Errorf("node %s: foreach subgraph must reference a registered graph by name", name)
+		}
+	case n.UnitID == "":
+		return fmt.Errorf("node %s is not backed by a registered unit", name)
+	default:
+		if _, ok := r.Find(n.UnitID); !ok {
+			return fmt.Errorf("node %s uses unknown unit %s", name, n.UnitID)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON 以 GraphJSON 格式输出，可由 BuildGraphFromJSON 还原
+func (g *Graph) MarshalJSON() ([]byte, error) {
+	def, err := g.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(def)
+}
+
+// UnmarshalJSON 等同于 BuildGraphFromJSON；已设置 Registry 时使用该注册表解析并保留它
+func (g *Graph) UnmarshalJSON(data []byte) error {
+	r := g.Registry
+	built, err := g.registry().BuildGraphFromJSON(data)
+	if err != nil {
+		return err
+	}
+	*g = *built
+	g.Registry = r
+	return nil
 }
 
 func Test_Json_To_Graph() {
@@ -64,13 +242,12 @@ func Test_Json_To_Graph() {
 					"data": "InputUnit Demo"
 				},
 				"params": {
-					"a": {"data": "input data"}
+					"openai": true
 				}
 			}
 		},
-		"edges": {
-			"req1": [""]
-		}
+		"start": "req1",
+		"edges": {}
 	}`)
 
 	graph, err := BuildGraphFromJSON(jsonData)
@@ -80,7 +257,11 @@ func Test_Json_To_Graph() {
 	}
 	fmt.Println("Graph built successfully:", graph)
 	//  完成了
-	result := make(ContextMap)
-	_ = graph.Run(context.Background(), "req1", result)
-	fmt.Printf("DAG Graph Tests result : %v\n", fn.Stringify(result))
+	result, err := graph.RunWithDSL(context.Background(), ContextMap{})
+	if err != nil {
+		fmt.Println("Run error:", err)
+	}
+	if result != nil {
+		fmt.Printf("DAG Graph Tests result : %v\n", fn.Stringify(result))
+	}
 }
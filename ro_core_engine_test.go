@@ -0,0 +1,132 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// greetUnit 测试用的注册单元，Greeting 由节点参数配置
+type greetUnit struct {
+	Unit
+	Greeting string `json:"greeting" desc:"问候语" required:"true"`
+	Suffix   string `json:"suffix,omitempty"`
+}
+
+func (u *greetUnit) GetUnitMeta() *Unit {
+	return &u.Unit
+}
+
+func (u *greetUnit) Execute(ctx context.Context, state ContextMap, self *Node) (*ExecutionResult, error) {
+	return SimpleResult(u.Greeting + u.Suffix), nil
+}
+
+func newTestRegistry(t *testing.T) *Registry {
+	t.Helper()
+	r := NewRegistry()
+	if err := r.RegisterUnit("test/greet@1.0.0", &greetUnit{}); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+const roundTripJSON = `{
+	"start": "hello",
+	"nodes": {
+		"hello": {"id": "n-hello", "unit_id": "test/greet", "name": "Hello", "parallel": true, "params": {"greeting": "hi"}, "retry": {"max_attempts": 2, "delay_ms": 5},
+			"timeout_ms": 1000, "export_fields": ["x"], "max_iterations": 3, "accumulate": true, "layout": {"x": 1.0}},
+		"bye": {"unit_id": "test/greet@1", "name": "Bye", "params": {"greeting": "bye", "suffix": "!"}, "join": "any"}
+	},
+	"edges": {"hello": ["bye"]},
+	"conditions": {"hello": {"bye": {"expr": "hello == \"hi\""}}},
+	"timeout_ms": 5000,
+	"fail_fast": true,
+	"max_concurrency": 2,
+	"event_retention_ms": -1,
+	"layout": {"zoom": 2.0}
+}`
+
+func TestGraphJSONRoundTrip(t *testing.T) {
+	r := newTestRegistry(t)
+	g, err := r.BuildGraphFromJSON([]byte(roundTripJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hello := g.Nodes["hello"]; !hello.Accumulate || hello.ID != "n-hello" || !hello.Parallel || g.Nodes["bye"].ID != "bye" {
+		t.Fatalf("node fields not built: %+v", hello)
+	}
+	if g.Timeout != 5*time.Second || g.EventRetention >= 0 {
+		t.Fatalf("graph fields not built: timeout %v, retention %v", g.Timeout, g.EventRetention)
+	}
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want, got GraphJSON
+	_ = json.Unmarshal([]byte(roundTripJSON), &want)
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(normalize(t, want), normalize(t, got)) {
+		t.Fatalf("round trip lost data:\nwant %s\ngot  %s", roundTripJSON, data)
+	}
+	res, err := g.RunWithDSL(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.State["bye"].Data != "bye!" {
+		t.Fatalf("unexpected output %v", res.State["bye"].Data)
+	}
+}
+
+// normalize 经 JSON 编解码后比较，忽略数值类型等差异
+func normalize(t *testing.T, def GraphJSON) any {
+	t.Helper()
+	data, err := json.Marshal(def)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out any
+	_ = json.Unmarshal(data, &out)
+	return out
+}
+
+func TestUnmarshalJSONKeepsRegistry(t *testing.T) {
+	r := newTestRegistry(t)
+	g := &Graph{Registry: r}
+	if err := json.Unmarshal([]byte(roundTripJSON), g); err != nil {
+		t.Fatal(err)
+	}
+	if g.Registry != r {
+		t.Fatal("registry was replaced")
+	}
+	if err := json.Unmarshal([]byte(roundTripJSON), &Graph{}); err == nil {
+		t.Fatal("default registry resolved a unit it does not know")
+	}
+}
+
+func TestToJSONRejectsFunctions(t *testing.T) {
+	g := NewDSLGraph()
+	g.StartWith("a", value(1))
+	if _, err := g.ToJSON(); err == nil {
+		t.Fatal("function node was exported")
+	}
+	cases := map[string]func(n *Node){
+		"retry condition": func(n *Node) { n.Retry = &RetryPolicy{MaxAttempts: 2, RetryIf: func(error) bool { return true }} },
+		"branch targets":  func(n *Node) { n.BranchTargets = []string{"bye"} },
+		"labels":          func(n *Node) { n.BranchLabels = map[string]string{"bye": "yes"} },
+	}
+	for name, change := range cases {
+		g, err := newTestRegistry(t).BuildGraphFromJSON([]byte(roundTripJSON))
+		if err != nil {
+			t.Fatal(err)
+		}
+		change(g.Nodes["hello"])
+		if _, err := g.ToJSON(); err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("%s: want an error naming it, got %v", name, err)
+		}
+	}
+}
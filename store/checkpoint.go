@@ -0,0 +1,126 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var ErrCheckpointNotFound = errors.New("checkpoint not found")
+
+// CheckpointStore 保存运行检查点，data 为序列化后的检查点
+type CheckpointStore interface {
+	Save(ctx context.Context, runID string, data []byte) error
+	Load(ctx context.Context, runID string) ([]byte, error)
+	Delete(ctx context.Context, runID string) error
+}
+
+// InMemoryCheckpointStore 进程内检查点，适合测试或单机短任务
+type InMemoryCheckpointStore struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+func NewInMemoryCheckpointStore() *InMemoryCheckpointStore {
+	return &InMemoryCheckpointStore{data: make(map[string][]byte)}
+}
+
+func (s *InMemoryCheckpointStore) Save(_ context.Context, runID string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data == nil {
+		s.data = make(map[string][]byte)
+	}
+	s.data[runID] = append([]byte(nil), data...)
+	return nil
+}
+
+func (s *InMemoryCheckpointStore) Load(_ context.Context, runID string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.data[runID]
+	if !ok {
+		return nil, ErrCheckpointNotFound
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func (s *InMemoryCheckpointStore) Delete(_ context.Context, runID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, runID)
+	return nil
+}
+
+// FileCheckpointStore 每个运行一个 JSON 文件，写入先落临时文件再 rename，避免写一半
+type FileCheckpointStore struct {
+	Dir string
+}
+
+func NewFileCheckpointStore(dir string) (*FileCheckpointStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileCheckpointStore{Dir: dir}, nil
+}
+
+var ErrInvalidRunID = errors.New("invalid run id")
+
+// path 完整的运行 ID 转义后作为文件名，子图的 "父运行 ID/节点" 不会与其他运行冲突
+func (s *FileCheckpointStore) path(runID string) (string, error) {
+	name := url.PathEscape(runID)
+	if runID == "" || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidRunID, runID)
+	}
+	return filepath.Join(s.Dir, name+".json"), nil
+}
+
+func (s *FileCheckpointStore) Save(_ context.Context, runID string, data []byte) error {
+	path, err := s.path(runID)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.Dir, "checkpoint-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *FileCheckpointStore) Load(_ context.Context, runID string) ([]byte, error) {
+	path, err := s.path(runID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrCheckpointNotFound
+	}
+	return data, err
+}
+
+func (s *FileCheckpointStore) Delete(_ context.Context, runID string) error {
+	path, err := s.path(runID)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
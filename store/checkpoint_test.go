@@ -0,0 +1,68 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileCheckpointStoreKeepsFullRunID(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewFileCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]string{
+		"run1/sub":     "a",
+		"run2/sub":     "b",
+		"run1/each[0]": "c",
+		"run1/each[1]": "d",
+	}
+	for id, data := range ids {
+		if err := s.Save(ctx, id, []byte(data)); err != nil {
+			t.Fatalf("save %s: %v", id, err)
+		}
+	}
+	for id, want := range ids {
+		got, err := s.Load(ctx, id)
+		if err != nil || string(got) != want {
+			t.Fatalf("load %s = %q, %v; want %q", id, got, err, want)
+		}
+	}
+	if err := s.Delete(ctx, "run1/sub"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Load(ctx, "run1/sub"); !errors.Is(err, ErrCheckpointNotFound) {
+		t.Fatalf("after delete: %v", err)
+	}
+	if got, _ := s.Load(ctx, "run2/sub"); string(got) != "b" {
+		t.Fatalf("run2/sub = %q", got)
+	}
+}
+
+func TestFileCheckpointStoreStaysInDir(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	dir := filepath.Join(root, "cp")
+	s, err := NewFileCheckpointStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"../escape", "..", `..\\escape`, "a/../../b"} {
+		if err := s.Save(ctx, id, []byte("x")); err != nil {
+			t.Fatalf("save %q: %v", id, err)
+		}
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "cp" {
+		t.Fatalf("files written outside the store: %v", entries)
+	}
+	if err := s.Save(ctx, "", []byte("x")); !errors.Is(err, ErrInvalidRunID) {
+		t.Fatalf("empty run id: %v", err)
+	}
+}
@@ -1,11 +1,50 @@
 package units
 
-import core "github.com/ninenhan/go-workflow"
+import (
+	core "github.com/ninenhan/go-workflow"
+	"github.com/ninenhan/go-workflow/flow"
+	"log/slog"
+	"sync"
+)
 
+var registerOnce sync.Once
+
+// AutoRegister 将所有内置单元注册到 core.DefaultRegistry，可重复调用。
+// 每个单元同时可在 Graph 与 Pipeline 中使用，ExecutableUnit 与 PhaseUnit 之间由适配器转换
 func AutoRegister() {
+	registerOnce.Do(func() {
+		if err := RegisterAll(core.DefaultRegistry); err != nil {
+			slog.Error("注册内置单元失败", "err", err)
+		}
+	})
+}
+
+// RegisterAll 将所有内置单元注册到 r，名称已被占用时返回错误
+func RegisterAll(r *core.Registry) error {
 	{
 		unit := &HttpUnit{}
-		// 自动注册 HttpUnit，注意这里注册的是非指针类型
-		core.RegisterUnit(unit.GetUnitName(), unit)
+		if err := r.RegisterUnit(unit.GetUnitName(), unit); err != nil {
+			return err
+		}
+	}
+	phases := []flow.PhaseUnit{
+		&flow.IfUnit{},
+		&flow.WhileUnit{},
+		&LogicUnit{},
+		&RemarkUnit{},
+		&ScriptUnit{},
+		&SetEnvUnit{},
+		&TerminalUnit{},
+		&TimeoutUnit{},
 	}
+	for _, unit := range phases {
+		if err := r.RegisterPhaseUnit(unit.GetUnitName(), unit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	AutoRegister()
 }
@@ -4,14 +4,17 @@ import (
 	"context"
 	"errors"
 	core "github.com/ninenhan/go-workflow"
+	"github.com/ninenhan/go-workflow/flow"
 	"github.com/ninenhan/go-workflow/fn"
 	xhttp "github.com/ninenhan/go-workflow/kit"
 	"log/slog"
 	"reflect"
+	"sync/atomic"
 )
 
 type HttpUnit struct {
 	core.Unit
+	OpenAI bool `json:"openai,omitempty" desc:"按 OpenAI 格式解析响应，流式增量合并为完整文本"` // 按 OpenAI 格式解析响应
 }
 
 var _ core.ExecutableUnit = (*HttpUnit)(nil) // ✅ 编译期检查
@@ -20,6 +23,14 @@ func (t *HttpUnit) GetUnitName() string {
 	return reflect.TypeOf(HttpUnit{}).Name()
 }
 
+func (t *HttpUnit) Describe(d *flow.UnitDescriptor) {
+	d.DisplayName = "HTTP 请求"
+	d.Category = "网络"
+	d.Description = "发送 HTTP 请求，text/event-stream 响应会实时推送并聚合为最终结果"
+	d.InputType = "json"
+	d.OutputType = "json"
+}
+
 func (t *HttpUnit) Execute(ctx context.Context, state core.ContextMap, self *core.Node) (*core.ExecutionResult, error) {
 	input := self.Input
 	request, e := fn.ConvertByJSON[any, xhttp.XRequest](input.Data)
@@ -28,27 +39,38 @@ func (t *HttpUnit) Execute(ctx context.Context, state core.ContextMap, self *cor
 		return nil, errors.New("invalid input type")
 	}
 	ch := make(chan any)
+	errCh := make(chan error, 1)
+	// 响应头到达后、第一个分片之前写入，读取分片时已可见
+	var streamed atomic.Bool
 	go func() {
-		err := xhttp.HandlerHttpWithChannel(request, false, ch)
+		err := xhttp.HandlerHttpWithChannelMode(ctx, request, t.OpenAI, ch, streamed.Store)
 		if err != nil {
 			slog.Error("调用失败", "err", err)
 		}
+		errCh <- err
 	}()
-	var result []any
+	// 流式分片实时推送给运行的订阅方，结束后聚合为下游使用的结果；非流式响应保持原有的 []any 结果
+	stream := core.NewStreamWriter(ctx)
+	var body []any
 	for message := range ch {
-		//TODO 如果是sse，可以通过event push出去
 		if data, ok := message.([]byte); ok {
-			result = append(result, string(data))
+			message = string(data)
+		}
+		if streamed.Load() {
+			stream.Write(message)
 		} else {
-			result = append(result, message)
+			body = append(body, message)
 		}
 	}
-	return &core.ExecutionResult{
-		NodeName: t.UnitName,
-		Data:     result,
-		Stream:   false,
-		Raw:      result,
-	}, nil
+	if err := <-errCh; err != nil {
+		return nil, err
+	}
+	if !streamed.Load() {
+		return &core.ExecutionResult{NodeName: t.UnitName, Data: body, Raw: body}, nil
+	}
+	result := stream.Result(xhttp.AggregateStream)
+	result.NodeName = t.UnitName
+	return result, nil
 }
 
 func (t *HttpUnit) GetUnitMeta() *core.Unit {
@@ -60,9 +82,3 @@ func NewHttpUnit() HttpUnit {
 	unit.UnitName = unit.GetUnitName()
 	return unit
 }
-
-func init() {
-	//unit := &HttpUnit{}
-	//// 自动注册 HttpUnit，注意这里注册的是非指针类型
-	//core.RegisterUnit(unit.GetUnitName(), unit)
-}
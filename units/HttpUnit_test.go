@@ -0,0 +1,66 @@
+package units
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	core "github.com/ninenhan/go-workflow"
+	"github.com/ninenhan/go-workflow/flow"
+)
+
+func runHttpUnit(t *testing.T, params map[string]any, handler http.HandlerFunc) (*core.RunResult, []any) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	var chunks []any
+	g := core.NewDSLGraph()
+	g.OnEvent(func(evt flow.Event) {
+		if evt.Type == flow.EventStreamChunk {
+			chunks = append(chunks, evt.Data)
+		}
+	})
+	g.AddNode("http", &core.Node{
+		UnitID: "HttpUnit",
+		Params: params,
+		Input:  &core.Input{Data: map[string]any{"url": srv.URL, "body": map[string]any{}}},
+	})
+	res, err := g.Run(context.Background(), "http", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res, chunks
+}
+
+func TestHttpUnitAggregatesOpenAIDeltas(t *testing.T) {
+	res, chunks := runHttpUnit(t, map[string]any{"openai": true}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, delta := range []string{"Hel", "lo"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", delta)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+	r := res.State["http"]
+	if !r.Stream || r.Data != "Hello" {
+		t.Fatalf("want aggregated stream, got stream=%v data=%v", r.Stream, r.Data)
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("want 2 chunk events, got %d", len(chunks))
+	}
+}
+
+func TestHttpUnitJSONResponseIsNotStream(t *testing.T) {
+	res, chunks := runHttpUnit(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"ok":true}`)
+	})
+	r := res.State["http"]
+	if body, ok := r.Data.([]any); r.Stream || !ok || len(body) != 1 || body[0] != `{"ok":true}` {
+		t.Fatalf("want plain result, got stream=%v data=%v", r.Stream, r.Data)
+	}
+	if len(chunks) != 0 {
+		t.Fatalf("non-stream response emitted %d chunks", len(chunks))
+	}
+}
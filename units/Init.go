@@ -14,14 +14,3 @@ func NewWhileUnit() flow.WhileUnit {
 	unit.UnitName = unit.GetUnitName()
 	return unit
 }
-
-func init() {
-	{
-		unit := &flow.IfUnit{}
-		flow.RegisterUnit(unit.GetUnitName(), unit)
-	}
-	{
-		unit := &flow.WhileUnit{}
-		flow.RegisterUnit(unit.GetUnitName(), unit)
-	}
-}
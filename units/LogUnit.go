@@ -13,6 +13,12 @@ func (t *LogicUnit) GetUnitName() string {
 	return reflect.TypeOf(LogicUnit{}).Name()
 }
 
+func (t *LogicUnit) Describe(d *flow.UnitDescriptor) {
+	d.DisplayName = "日志"
+	d.Category = "工具"
+	d.Description = "原样输出输入数据"
+}
+
 func (t *LogicUnit) Execute(ctx *flow.PipelineContext, i *flow.Input) (*flow.Output, error) {
 	if t.IOConfig == nil {
 		t.IOConfig = &flow.IOConfig{}
@@ -29,9 +35,3 @@ func NewLogUnit() LogicUnit {
 	unit.UnitName = unit.GetUnitName()
 	return unit
 }
-
-func init() {
-	unit := &LogicUnit{}
-	// 自动注册 HttpUnit，注意这里注册的是非指针类型
-	flow.RegisterUnit(unit.GetUnitName(), unit)
-}
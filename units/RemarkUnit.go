@@ -13,6 +13,12 @@ func (t *RemarkUnit) GetUnitName() string {
 	return reflect.TypeOf(RemarkUnit{}).Name()
 }
 
+func (t *RemarkUnit) Describe(d *flow.UnitDescriptor) {
+	d.DisplayName = "备注"
+	d.Category = "工具"
+	d.Description = "备注说明，原样输出输入数据"
+}
+
 func (t *RemarkUnit) Execute(ctx *flow.PipelineContext, i *flow.Input) (*flow.Output, error) {
 	if t.IOConfig == nil {
 		t.IOConfig = &flow.IOConfig{}
@@ -29,9 +35,3 @@ func NewRemarkUnit() RemarkUnit {
 	unit.UnitName = unit.GetUnitName()
 	return unit
 }
-
-func init() {
-	unit := &RemarkUnit{}
-	// 自动注册 HttpUnit，注意这里注册的是非指针类型
-	flow.RegisterUnit(unit.GetUnitName(), unit)
-}
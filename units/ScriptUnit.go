@@ -1,6 +1,7 @@
 package units
 
 import (
+	"context"
 	"fmt"
 	"github.com/dop251/goja"
 	"github.com/ninenhan/go-workflow/flow"
@@ -11,15 +12,29 @@ import (
 // ScriptUnit ===== ScriptUnit 动态 JS 执行单元 =====
 type ScriptUnit struct {
 	flow.BaseUnit
-	Script string `json:"script"` // JavaScript 脚本代码
+	Script string `json:"script" desc:"JavaScript 脚本代码" required:"true"` // JavaScript 脚本代码
 }
 
 func (t *ScriptUnit) GetUnitName() string {
 	return reflect.TypeOf(ScriptUnit{}).Name()
 }
 
+func (t *ScriptUnit) Describe(d *flow.UnitDescriptor) {
+	d.DisplayName = "脚本"
+	d.Category = "脚本"
+	d.Description = "执行 JavaScript，环境变量以 $ 前缀注入，结果包含返回值与 $$ 开头的全局变量"
+	d.OutputType = "json"
+}
+
 func (t *ScriptUnit) Execute(ctx *flow.PipelineContext, input *flow.Input) (*flow.Output, error) {
 	vm := goja.New()
+	// 运行被取消或中断时终止脚本
+	if ctx.Context != nil {
+		stop := context.AfterFunc(ctx.Context, func() {
+			vm.Interrupt(context.Cause(ctx.Context))
+		})
+		defer stop()
+	}
 	// 注入上下文变量
 	for k, v := range ctx.Env {
 		_ = vm.Set("$"+k, v)
@@ -58,9 +73,3 @@ func NewScriptUnit(script string) ScriptUnit {
 	unit.UnitName = unit.GetUnitName()
 	return unit
 }
-
-func init() {
-	unit := &ScriptUnit{}
-	// 自动注册 HttpUnit，注意这里注册的是非指针类型
-	flow.RegisterUnit(unit.GetUnitName(), unit)
-}
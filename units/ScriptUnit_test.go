@@ -0,0 +1,35 @@
+package units
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	core "github.com/ninenhan/go-workflow"
+)
+
+func TestScriptUnitParamsAreChecked(t *testing.T) {
+	for _, params := range []string{`{"scrpt": "1+1"}`, `{}`} {
+		_, err := core.BuildGraphFromJSON([]byte(`{"nodes": {"s": {"unit_id": "ScriptUnit", "params": ` + params + `}}}`))
+		var ce *core.CompileError
+		if !errors.As(err, &ce) || !hasInvalidParams(ce.Diagnostics) {
+			t.Errorf("params %s: want invalid_params, got %v", params, err)
+		}
+	}
+	g, err := core.BuildGraphFromJSON([]byte(`{"nodes": {"s": {"unit_id": "ScriptUnit", "params": {"script": "1+1"}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.RunWithDSL(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func hasInvalidParams(ds core.Diagnostics) bool {
+	for _, d := range ds {
+		if d.Code == core.DiagInvalidParams {
+			return true
+		}
+	}
+	return false
+}
@@ -15,6 +15,14 @@ func (t *SetEnvUnit) GetUnitName() string {
 	return reflect.TypeOf(SetEnvUnit{}).Name()
 }
 
+func (t *SetEnvUnit) Describe(d *flow.UnitDescriptor) {
+	d.DisplayName = "设置环境变量"
+	d.Category = "工具"
+	d.Description = "将输入的对象逐项写入环境变量"
+	d.InputType = "json"
+	d.OutputType = "json"
+}
+
 func (t *SetEnvUnit) Execute(ctx *flow.PipelineContext, i *flow.Input) (*flow.Output, error) {
 	if t.IOConfig == nil {
 		t.IOConfig = &flow.IOConfig{}
@@ -45,9 +53,3 @@ func NewSetEnvUnit() SetEnvUnit {
 	unit.UnitName = unit.GetUnitName()
 	return unit
 }
-
-func init() {
-	unit := &SetEnvUnit{}
-	// 自动注册 HttpUnit，注意这里注册的是非指针类型
-	flow.RegisterUnit(unit.GetUnitName(), unit)
-}
@@ -14,6 +14,12 @@ func (t *TerminalUnit) GetUnitName() string {
 	return reflect.TypeOf(TerminalUnit{}).Name()
 }
 
+func (t *TerminalUnit) Describe(d *flow.UnitDescriptor) {
+	d.DisplayName = "终止"
+	d.Category = "控制"
+	d.Description = "结束流水线"
+}
+
 func (t *TerminalUnit) Execute(ctx *flow.PipelineContext, i *flow.Input) (*flow.Output, error) {
 	return nil, fmt.Errorf("TerminalUnit %s", "执行结束")
 }
@@ -23,9 +29,3 @@ func NewTerminalUnit() TerminalUnit {
 	unit.UnitName = unit.GetUnitName()
 	return unit
 }
-
-func init() {
-	unit := &TerminalUnit{}
-	// 自动注册 HttpUnit，注意这里注册的是非指针类型
-	flow.RegisterUnit(unit.GetUnitName(), unit)
-}
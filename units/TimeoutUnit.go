@@ -16,6 +16,13 @@ func (t *TimeoutUnit) GetUnitName() string {
 	return reflect.TypeOf(TimeoutUnit{}).Name()
 }
 
+func (t *TimeoutUnit) Describe(d *flow.UnitDescriptor) {
+	d.DisplayName = "等待"
+	d.Category = "控制"
+	d.Description = "等待输入指定的毫秒数，默认 1 秒"
+	d.InputType = "plaintext"
+}
+
 func (t *TimeoutUnit) Execute(ctx *flow.PipelineContext, i *flow.Input) (*flow.Output, error) {
 	val, ok := i.Data.(string)
 	timeout := 1 * time.Second
@@ -39,9 +46,3 @@ func NewTimeoutUnit() TimeoutUnit {
 	unit.UnitName = unit.GetUnitName()
 	return unit
 }
-
-func init() {
-	unit := &TimeoutUnit{}
-	// 自动注册 HttpUnit，注意这里注册的是非指针类型
-	flow.RegisterUnit(unit.GetUnitName(), unit)
-}